@@ -3,12 +3,14 @@ package analysis
 import (
 	"fmt"
 	"go/ast"
-	"go/parser"
+	"go/build/constraint"
 	"go/token"
 	"log"
 	"os"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 	"unicode/utf8"
 
 	"github.com/fatih/set"
@@ -19,10 +21,115 @@ type analyzer struct {
 	fileset                 *token.FileSet
 	debug                   bool
 	declaredFuncInfo        map[string]BlanketFunc
+	suppressedFuncs         map[string]SuppressedFunc
+	generatedFuncs          *set.Set
+	now                     func() time.Time
 	calledFuncs             *set.Set
+	categoryCalledFuncs     map[string]*set.Set
+	testCategory            string
+	directCalledFuncs       *set.Set
+	helperCalledFuncs       *set.Set
+	viaHelper               bool
+	testFuncs               map[string]testFunc
+	testHelpers             *set.Set
+	currentTest             string
+	calledBy                map[string]*set.Set
+	testPackage             string
+	reachedTestFuncs        []reachedFunc
+	callDepth               int
+	callDepths              map[string]int
+	callSink                *set.Set
+	productionCalls         map[string]*set.Set
 	helperFunctionReturnMap map[string][]string
 	nameToTypeMap           map[string]string
+	structFieldMap          map[string]map[string]string
+	embeddedTypeMap         map[string][]string
+	interfaceTypes          map[string]bool
+	interfaceImpls          map[string][]string
+	interfaceCalledFuncs    *set.Set
 	latestReport            *BlanketReport
+	importPath              string
+	packageName             string
+	packageDir              string
+	importedAs              string
+	options                 Options
+}
+
+// unitTestCategory is the category calls from test files without a build constraint are attributed to.
+const unitTestCategory = "unit"
+
+// buildCategory returns the category calls from a given test file are attributed to, which is the
+// file's build constraint (i.e. `integration` for a file starting with `//go:build integration`).
+func buildCategory(in *ast.File) string {
+	for _, group := range in.Comments {
+		if group.Pos() >= in.Package {
+			break
+		}
+		for _, c := range group.List {
+			if !constraint.IsGoBuild(c.Text) {
+				continue
+			}
+			if expr, err := constraint.Parse(c.Text); err == nil {
+				return expr.String()
+			}
+		}
+	}
+	return unitTestCategory
+}
+
+// markCalled records a direct call of a given function, attributing it to the test being parsed, to the category
+// of the test file it came from, and to either the body of a test or one of the test helpers it calls.
+func (a *analyzer) markCalled(name string) {
+	if a.callSink != nil {
+		// we're building the call graph of the production code, which says nothing about what tests call.
+		a.callSink.Add(name)
+		return
+	}
+
+	a.reachTestFunc(name)
+	if depth, ok := a.callDepths[name]; !ok || a.callDepth < depth {
+		a.callDepths[name] = a.callDepth
+	}
+	if a.currentTest != "" {
+		if _, ok := a.calledBy[name]; !ok {
+			a.calledBy[name] = set.New()
+		}
+		a.calledBy[name].Add(a.currentTest)
+	}
+
+	if a.viaHelper {
+		a.helperCalledFuncs.Add(name)
+		if a.options.IndirectHelperCalls {
+			return
+		}
+	} else {
+		a.directCalledFuncs.Add(name)
+	}
+	a.calledFuncs.Add(name)
+
+	if a.testCategory == "" {
+		return
+	}
+
+	if _, ok := a.categoryCalledFuncs[a.testCategory]; !ok {
+		a.categoryCalledFuncs[a.testCategory] = set.New()
+	}
+	a.categoryCalledFuncs[a.testCategory].Add(name)
+}
+
+// reachTestFunc queues a given function to be parsed if it's one of the functions declared in the test files,
+// so that the calls made by helpers are only counted when a test actually uses the helper. Everything a test
+// helper reaches is mediated by that helper, too.
+func (a *analyzer) reachTestFunc(name string) {
+	key := testFuncKey(a.testPackage, name)
+	if _, ok := a.testFuncs[key]; ok {
+		a.reachedTestFuncs = append(a.reachedTestFuncs, reachedFunc{
+			key:       key,
+			depth:     a.callDepth + 1,
+			viaHelper: a.viaHelper || a.testHelpers.Has(key),
+			category:  a.testCategory,
+		})
+	}
 }
 
 func (a *analyzer) parseExpr(in ast.Expr) {
@@ -30,32 +137,208 @@ func (a *analyzer) parseExpr(in ast.Expr) {
 	switch f := in.(type) {
 	case *ast.Ident:
 		functionName := f.Name
-		a.calledFuncs.Add(functionName)
+		a.markCalled(functionName)
 	case *ast.SelectorExpr:
 		if x, ok := f.X.(*ast.Ident); ok {
 			structVarName := x.Name
 			calledMethodName := f.Sel.Name
-			if _, ok := a.nameToTypeMap[structVarName]; ok {
-				a.calledFuncs.Add(fmt.Sprintf("%s.%s", a.nameToTypeMap[structVarName], calledMethodName))
+			if _, ok := a.nameToTypeMap[structVarName]; !ok && a.importedAs != "" && structVarName == a.importedAs {
+				// a package-qualified call to the package under analysis, i.e. `foo.Bar()` in package foo_test
+				a.markCalled(calledMethodName)
+			} else if ok {
+				a.markCalled(fmt.Sprintf("%s.%s", a.nameToTypeMap[structVarName], calledMethodName))
+				a.parseInterfaceCall(structVarName, calledMethodName)
 			}
+		} else if typeName := a.resolveExprType(f.X); typeName != "" {
+			a.markCalled(fmt.Sprintf("%s.%s", typeName, f.Sel.Name))
 		}
+	case *ast.IndexExpr: // instantiation of a generic function, i.e. `NewStack[int]()`
+		a.parseExpr(f.X)
+	case *ast.IndexListExpr:
+		a.parseExpr(f.X)
 	case *ast.FuncLit:
 		a.parseFuncLit(f)
 	}
 }
 
+// resolveExprType returns the name of the type a given expression evaluates to, following
+// selector chains (i.e. `x.First.Second.Third`) through the struct fields declared in the
+// package. It returns an empty string when the type can't be determined.
+func (a *analyzer) resolveExprType(in ast.Expr) string {
+	switch e := in.(type) {
+	case *ast.Ident:
+		return a.nameToTypeMap[e.Name]
+	case *ast.SelectorExpr:
+		if parentType := a.resolveExprType(e.X); parentType != "" {
+			return a.fieldType(parentType, e.Sel.Name, set.New())
+		}
+	case *ast.ParenExpr:
+		return a.resolveExprType(e.X)
+	case *ast.StarExpr:
+		return a.resolveExprType(e.X)
+	case *ast.CallExpr: // i.e. `New().Method()`
+		if returnTypes := a.helperFunctionReturnMap[calledFunctionName(e)]; len(returnTypes) > 0 {
+			return returnTypes[0]
+		}
+	}
+	return ""
+}
+
+// concreteTypeOf returns the name of the concrete type a given expression evaluates to (i.e. `memStore`
+// for `&memStore{}`), or an empty string if it can't be determined or is itself an interface.
+func (a *analyzer) concreteTypeOf(in ast.Expr) string {
+	var typeName string
+	switch e := in.(type) {
+	case *ast.UnaryExpr:
+		return a.concreteTypeOf(e.X)
+	case *ast.CompositeLit:
+		typeName = typeExprName(e.Type)
+	default:
+		typeName = a.resolveExprType(e)
+	}
+
+	if a.interfaceTypes[typeName] {
+		return ""
+	}
+	return typeName
+}
+
+// recordInterfaceImpl remembers the concrete type assigned to a variable of one of the package's
+// interface types, so that method calls made through that variable can be mapped to the
+// implementation they dispatch to.
+func (a *analyzer) recordInterfaceImpl(varName string, value ast.Expr) {
+	if !a.options.InterfaceCalls || !a.interfaceTypes[a.nameToTypeMap[varName]] {
+		return
+	}
+
+	if concreteType := a.concreteTypeOf(value); concreteType != "" {
+		a.interfaceImpls[varName] = append(a.interfaceImpls[varName], concreteType)
+	}
+}
+
+// parseInterfaceCall records the implementations a method call through a variable of interface
+// type (i.e. `s.Get()` after `var s Store = &memStore{}`) dispatches to.
+func (a *analyzer) parseInterfaceCall(varName, methodName string) {
+	if !a.interfaceTypes[a.nameToTypeMap[varName]] || a.callSink != nil {
+		return
+	}
+
+	for _, impl := range a.interfaceImpls[varName] {
+		a.interfaceCalledFuncs.Add(fmt.Sprintf("%s.%s", impl, methodName))
+	}
+}
+
+// fieldType returns the type of a given field of a struct type, including fields promoted from
+// the types it embeds (i.e. `svc.cacheSize` where `Service` embeds `*Cache`).
+func (a *analyzer) fieldType(typeName, fieldName string, seen *set.Set) string {
+	if fieldType, ok := a.structFieldMap[typeName][fieldName]; ok {
+		return fieldType
+	}
+
+	// embedding a pointer to the type itself is perfectly legal, so keep track of where we've been.
+	seen.Add(typeName)
+	for _, embedded := range a.embeddedTypeMap[typeName] {
+		if seen.Has(embedded) {
+			continue
+		}
+		if fieldType := a.fieldType(embedded, fieldName, seen); fieldType != "" {
+			return fieldType
+		}
+	}
+	return ""
+}
+
+// promotedMethodName returns the name a method called on a given type is actually declared under,
+// following embedded fields breadth first the same way the compiler does, so that `Service.Lookup`
+// becomes `Cache.Lookup` when `Service` embeds `*Cache`. Names that can't be resolved are returned as is.
+func (a *analyzer) promotedMethodName(name string) string {
+	if _, ok := a.declaredFuncInfo[name]; ok {
+		return name
+	}
+
+	parts := strings.SplitN(name, ".", 2)
+	if len(parts) != 2 {
+		return name
+	}
+
+	typeName, methodName := parts[0], parts[1]
+	seen := set.New(typeName)
+	queue := a.embeddedTypeMap[typeName]
+	for len(queue) > 0 {
+		var next []string
+		for _, embedded := range queue {
+			if seen.Has(embedded) {
+				continue
+			}
+			seen.Add(embedded)
+
+			candidate := fmt.Sprintf("%s.%s", embedded, methodName)
+			if _, ok := a.declaredFuncInfo[candidate]; ok {
+				return candidate
+			}
+			next = append(next, a.embeddedTypeMap[embedded]...)
+		}
+		queue = next
+	}
+	return name
+}
+
+// resolvePromotedMethods replaces every promoted method call in a given set with the method it refers to.
+func (a *analyzer) resolvePromotedMethods(in *set.Set) {
+	for _, x := range set.StringSlice(in) {
+		if resolved := a.promotedMethodName(x); resolved != x {
+			in.Remove(x)
+			in.Add(resolved)
+		}
+	}
+}
+
+// calledFunctionName returns the bare name of the function a call expression calls, i.e. `New` for `pkg.New[T]()`.
+func calledFunctionName(in *ast.CallExpr) string {
+	switch funcInfo := in.Fun.(type) {
+	case *ast.Ident:
+		return funcInfo.Name
+	case *ast.SelectorExpr:
+		return funcInfo.Sel.Name
+	case *ast.IndexExpr, *ast.IndexListExpr:
+		return typeExprName(funcInfo)
+	}
+	return ""
+}
+
 func (a *analyzer) parseCallExpr(in *ast.CallExpr) {
 	for _, arg := range in.Args {
-		switch at := arg.(type) {
-		// case *ast.Ident:
-		case *ast.CallExpr:
-			a.parseCallExpr(at)
-		case *ast.FuncLit:
-			a.parseFuncLit(at)
-		}
+		a.parseValueExpr(arg)
 	}
 
 	a.parseExpr(in.Fun)
+	if _, ok := in.Fun.(*ast.FuncLit); !ok {
+		// catches calls the function expression itself is built from, i.e. `New().Method()` or `handlers[key()]()`
+		a.parseNestedExpr(in.Fun)
+	}
+}
+
+// parseNestedExpr finds the calls, function literals and composite literals nested anywhere inside
+// an arbitrary expression, such as a condition, a case expression, or an operand of a binary expression.
+func (a *analyzer) parseNestedExpr(in ast.Expr) {
+	if in == nil {
+		return
+	}
+
+	ast.Inspect(in, func(n ast.Node) bool {
+		switch x := n.(type) {
+		case *ast.CallExpr:
+			a.parseCallExpr(x)
+			return false
+		case *ast.FuncLit:
+			a.parseFuncLit(x)
+			return false
+		case *ast.CompositeLit:
+			a.parseCompositeElts(x)
+			return false
+		}
+		return true
+	})
 }
 
 // parseUnaryExpr parses Unary expressions. From the go/ast docs:
@@ -63,21 +346,14 @@ func (a *analyzer) parseCallExpr(in *ast.CallExpr) {
 // (handles declarations like `callExpr := &ast.UnaryExpr{}` or `callExpr := ast.UnaryExpr{}`)
 func (a *analyzer) parseUnaryExpr(in *ast.UnaryExpr, varName string) {
 	if cl, ok := in.X.(*ast.CompositeLit); ok {
-		for _, e := range cl.Elts {
-			switch et := e.(type) {
-			case *ast.CallExpr:
-				a.parseExpr(et.Fun)
-			case *ast.KeyValueExpr:
-				if vt, ok := et.Value.(*ast.CallExpr); ok {
-					a.parseCallExpr(vt)
-				}
-			}
-		}
+		a.parseCompositeElts(cl)
 		switch u := cl.Type.(type) {
 		case *ast.Ident:
 			a.nameToTypeMap[varName] = u.Name
 		case *ast.SelectorExpr:
 			a.nameToTypeMap[varName] = u.Sel.Name
+		case *ast.IndexExpr, *ast.IndexListExpr:
+			a.nameToTypeMap[varName] = typeExprName(u)
 		}
 	}
 }
@@ -87,8 +363,8 @@ func (a *analyzer) parseUnaryExpr(in *ast.UnaryExpr, varName string) {
 // DeclStmts come from function bodies, GenDecls come from package-wide const or var declarations
 func (a *analyzer) parseDeclStmt(in *ast.DeclStmt) {
 	if gd, ok := in.Decl.(*ast.GenDecl); ok {
-		if len(gd.Specs) > 0 {
-			if s, ok := gd.Specs[0].(*ast.ValueSpec); ok {
+		for _, spec := range gd.Specs {
+			if s, ok := spec.(*ast.ValueSpec); ok {
 				if len(s.Names) > 0 {
 					varName := s.Names[0].Name
 					switch t := s.Type.(type) {
@@ -96,6 +372,18 @@ func (a *analyzer) parseDeclStmt(in *ast.DeclStmt) {
 						a.nameToTypeMap[varName] = t.Name
 					case *ast.SelectorExpr:
 						a.nameToTypeMap[varName] = t.Sel.Name
+					case *ast.StarExpr, *ast.IndexExpr, *ast.IndexListExpr:
+						if typeName := typeExprName(t); typeName != "" {
+							a.nameToTypeMap[varName] = typeName
+						}
+					}
+					// a fresh declaration can't hold anything assigned to a previous variable of the same name.
+					delete(a.interfaceImpls, varName)
+				}
+				for i, v := range s.Values {
+					a.parseValueExpr(v)
+					if i < len(s.Names) {
+						a.recordInterfaceImpl(s.Names[i].Name, v)
 					}
 				}
 			}
@@ -108,21 +396,78 @@ func (a *analyzer) parseDeclStmt(in *ast.DeclStmt) {
 func (a *analyzer) parseExprStmt(in *ast.ExprStmt) {
 	if c, ok := in.X.(*ast.CallExpr); ok {
 		a.parseCallExpr(c)
+	} else {
+		// i.e. a receive statement like `<-done()`
+		a.parseNestedExpr(in.X)
 	}
 }
 
-func (a *analyzer) parseCompositeLit(in *ast.CompositeLit, varName string) {
+// parseCompositeElts walks the elements of a composite literal, including nested literals like
+// the cases of a table-driven test, looking for function calls and function values.
+func (a *analyzer) parseCompositeElts(in *ast.CompositeLit) {
 	for _, e := range in.Elts {
-		if et, ok := e.(*ast.CallExpr); ok {
-			a.parseExpr(et.Fun)
+		if kv, ok := e.(*ast.KeyValueExpr); ok {
+			// keys are either field names or map keys, neither of which we care about.
+			e = kv.Value
+		}
+		a.parseValueExpr(e)
+	}
+}
+
+// parseValueExpr parses an expression whose value is used (i.e. the right hand side of a
+// declaration, or an element of a composite literal), rather than one that is called.
+func (a *analyzer) parseValueExpr(in ast.Expr) {
+	switch v := in.(type) {
+	case *ast.CallExpr:
+		a.parseCallExpr(v)
+	case *ast.FuncLit:
+		a.parseFuncLit(v)
+	case *ast.CompositeLit:
+		a.parseCompositeElts(v)
+	case *ast.UnaryExpr:
+		a.parseValueExpr(v.X)
+	case *ast.ParenExpr:
+		a.parseValueExpr(v.X)
+	case *ast.Ident, *ast.SelectorExpr:
+		a.parseFuncValue(v)
+	default:
+		a.parseNestedExpr(v)
+	}
+}
+
+// parseFuncValue records functions and methods that are referenced without being called, such as
+// `run(t, Parse)`, `http.HandlerFunc(handleIndex)` or `{name: "a", fn: A}`. Those count as direct
+// references unless the analyzer has been told to ignore function values.
+func (a *analyzer) parseFuncValue(in ast.Expr) {
+	if ident, ok := in.(*ast.Ident); ok {
+		// helpers passed around as values (i.e. `t.Run("name", subtest)`) are reached all the same.
+		a.reachTestFunc(ident.Name)
+	}
+
+	if a.options.IgnoreFuncValues {
+		return
+	}
+
+	if ident, ok := in.(*ast.Ident); ok {
+		// bare identifiers are far more likely to be variables than functions, so only
+		// record the ones we know are functions declared in the package.
+		if _, ok := a.declaredFuncInfo[ident.Name]; !ok {
+			return
 		}
 	}
+	a.parseExpr(in)
+}
+
+func (a *analyzer) parseCompositeLit(in *ast.CompositeLit, varName string) {
+	a.parseCompositeElts(in)
 
 	switch t := in.Type.(type) {
 	case *ast.Ident:
 		a.nameToTypeMap[varName] = t.Name
 	case *ast.SelectorExpr:
 		a.nameToTypeMap[varName] = t.Sel.Name
+	case *ast.IndexExpr, *ast.IndexListExpr:
+		a.nameToTypeMap[varName] = typeExprName(t)
 	}
 }
 
@@ -140,6 +485,9 @@ func (a *analyzer) parseGenDecl(in *ast.GenDecl) {
 					}
 				}
 			}
+			for _, v := range global.Values {
+				a.parseValueExpr(v)
+			}
 		}
 	}
 }
@@ -152,13 +500,10 @@ func (a *analyzer) parseFuncDecl(f *ast.FuncDecl) string {
 	var parentName string
 	if f.Recv != nil {
 		if len(f.Recv.List) > 0 {
+			// methods on generic types (i.e. `func (s *Stack[T]) Push(v T)`) are keyed by their base type.
 			switch x := f.Recv.List[0].Type.(type) {
-			case *ast.StarExpr:
-				if parent, ok := x.X.(*ast.Ident); ok {
-					parentName = parent.Name
-				}
-			case *ast.Ident:
-				parentName = x.Obj.Name
+			case *ast.StarExpr, *ast.Ident, *ast.IndexExpr, *ast.IndexListExpr:
+				parentName = typeExprName(x)
 			}
 		}
 	}
@@ -173,10 +518,21 @@ func (a *analyzer) parseFuncDecl(f *ast.FuncDecl) string {
 //    An AssignStmt node represents an assignment or a short variable declaration
 func (a *analyzer) parseAssignStmt(in *ast.AssignStmt) {
 	leftHandSide := []string{}
+	interfaceTypes := map[string]string{}
 	for i := range in.Lhs {
 		if l, ok := in.Lhs[i].(*ast.Ident); ok {
 			varName := l.Name
 			leftHandSide = append(leftHandSide, varName)
+			if in.Tok == token.ASSIGN && a.interfaceTypes[a.nameToTypeMap[varName]] {
+				// assigning to a variable of interface type doesn't change its type, only what it holds.
+				interfaceTypes[varName] = a.nameToTypeMap[varName]
+			} else {
+				delete(a.interfaceImpls, varName)
+			}
+		} else {
+			// i.e. `m[key()] = value` or `s.field = value`
+			leftHandSide = append(leftHandSide, "")
+			a.parseNestedExpr(in.Lhs[i])
 		}
 	}
 
@@ -185,7 +541,18 @@ func (a *analyzer) parseAssignStmt(in *ast.AssignStmt) {
 		case *ast.FuncLit:
 			a.parseFuncLit(t)
 		case *ast.UnaryExpr:
-			a.parseUnaryExpr(t, leftHandSide[j])
+			if _, ok := t.X.(*ast.CompositeLit); ok && len(leftHandSide) > j {
+				a.parseUnaryExpr(t, leftHandSide[j])
+			} else {
+				a.parseValueExpr(t)
+			}
+		case *ast.Ident:
+			a.parseFuncValue(t)
+		case *ast.SelectorExpr:
+			if typeName := a.resolveExprType(t); typeName != "" && len(leftHandSide) > j {
+				a.nameToTypeMap[leftHandSide[j]] = typeName
+			}
+			a.parseFuncValue(t)
 		case *ast.CompositeLit:
 			if len(leftHandSide) > j {
 				a.parseCompositeLit(t, leftHandSide[j])
@@ -193,19 +560,30 @@ func (a *analyzer) parseAssignStmt(in *ast.AssignStmt) {
 				a.parseCompositeLit(t, "")
 			}
 		case *ast.CallExpr:
-			var functionName string
-			switch funcInfo := t.Fun.(type) {
-			case *ast.Ident:
-				functionName = funcInfo.Name
-			case *ast.SelectorExpr:
-				functionName = funcInfo.Sel.Name
-			}
+			functionName := calledFunctionName(t)
 
 			a.parseCallExpr(t)
-			if _, ok := a.helperFunctionReturnMap[functionName]; ok {
+			if returnTypes, ok := a.helperFunctionReturnMap[functionName]; ok {
 				for i, thing := range leftHandSide {
-					a.nameToTypeMap[thing] = a.helperFunctionReturnMap[functionName][i]
+					if thing != "" && i < len(returnTypes) {
+						a.nameToTypeMap[thing] = returnTypes[i]
+					}
 				}
+			} else if a.interfaceTypes[functionName] && len(t.Args) == 1 && len(leftHandSide) > j && leftHandSide[j] != "" {
+				// a conversion to one of the package's interfaces, i.e. `s := Store(&memStore{})`
+				a.nameToTypeMap[leftHandSide[j]] = functionName
+				a.recordInterfaceImpl(leftHandSide[j], t.Args[0])
+			}
+		default:
+			a.parseValueExpr(t)
+		}
+
+		if len(leftHandSide) > j && leftHandSide[j] != "" {
+			if interfaceType, ok := interfaceTypes[leftHandSide[j]]; ok {
+				a.nameToTypeMap[leftHandSide[j]] = interfaceType
+			}
+			if in.Tok == token.ASSIGN {
+				a.recordInterfaceImpl(leftHandSide[j], in.Rhs[j])
 			}
 		}
 	}
@@ -215,19 +593,35 @@ func (a *analyzer) parseHelperSelectorExpr(in *ast.SelectorExpr, functionName st
 	if pkg, ok := in.X.(*ast.Ident); ok {
 		pkgName := pkg.Name
 		pkgStruct := in.Sel.Name
+		if a.importedAs != "" && pkgName == a.importedAs {
+			// types from the package under analysis are referred to by their bare names everywhere else.
+			a.helperFunctionReturnMap[functionName] = append(a.helperFunctionReturnMap[functionName], pkgStruct)
+			return
+		}
 		a.helperFunctionReturnMap[functionName] = append(a.helperFunctionReturnMap[functionName], fmt.Sprintf("%s.%s", pkgName, pkgStruct))
 	}
 }
 
+// stripTypeArgs returns the generic type a given instantiation refers to, i.e. `Stack` for `Stack[T]`.
+func stripTypeArgs(in ast.Expr) ast.Expr {
+	switch t := in.(type) {
+	case *ast.IndexExpr:
+		return t.X
+	case *ast.IndexListExpr:
+		return t.X
+	}
+	return in
+}
+
 func (a *analyzer) parseHelperFunction(in *ast.FuncDecl) {
 	functionName := in.Name.Name
 	if in.Type.Results != nil {
 		for _, r := range in.Type.Results.List {
-			switch rt := r.Type.(type) {
+			switch rt := stripTypeArgs(r.Type).(type) {
 			case *ast.SelectorExpr:
 				a.parseHelperSelectorExpr(rt, functionName)
 			case *ast.StarExpr:
-				switch x := rt.X.(type) {
+				switch x := stripTypeArgs(rt.X).(type) {
 				case *ast.Ident:
 					a.helperFunctionReturnMap[functionName] = append(a.helperFunctionReturnMap[functionName], x.Name)
 				case *ast.SelectorExpr:
@@ -251,15 +645,37 @@ func (a *analyzer) parseFuncLit(in *ast.FuncLit) {
 
 func (a *analyzer) parseReturnStmt(in *ast.ReturnStmt) {
 	for _, x := range in.Results {
-		if y, ok := x.(*ast.CallExpr); ok {
-			a.parseExpr(y.Fun)
-		}
+		a.parseValueExpr(x)
+	}
+}
+
+// parseBlockStmt parses every statement in a block. From the go/ast docs:
+// 		A BlockStmt node represents a braced statement list.
+func (a *analyzer) parseBlockStmt(in *ast.BlockStmt) {
+	if in == nil {
+		return
+	}
+	for _, x := range in.List {
+		a.parseStmt(x)
+	}
+}
+
+// parseCaseClause parses a case of an expression or type switch, including the case's expressions.
+func (a *analyzer) parseCaseClause(in *ast.CaseClause) {
+	for _, x := range in.List {
+		a.parseNestedExpr(x)
+	}
+	for _, x := range in.Body {
+		a.parseStmt(x)
 	}
 }
 
 func (a *analyzer) parseSelectStmt(in *ast.SelectStmt) {
 	for _, x := range in.Body.List {
 		if y, ok := x.(*ast.CommClause); ok {
+			if y.Comm != nil {
+				a.parseStmt(y.Comm)
+			}
 			for _, z := range y.Body {
 				a.parseStmt(z)
 			}
@@ -269,70 +685,99 @@ func (a *analyzer) parseSelectStmt(in *ast.SelectStmt) {
 
 // parseSendStmt parses a send statement. (<-)
 func (a *analyzer) parseSendStmt(in *ast.SendStmt) {
-	if n, ok := in.Value.(*ast.CallExpr); ok {
-		a.parseCallExpr(n)
-	}
+	a.parseNestedExpr(in.Chan)
+	a.parseValueExpr(in.Value)
 }
 
 func (a *analyzer) parseSwitchStmt(in *ast.SwitchStmt) {
+	if in.Init != nil {
+		a.parseStmt(in.Init)
+	}
+	a.parseNestedExpr(in.Tag)
 	for _, x := range in.Body.List {
 		if y, ok := x.(*ast.CaseClause); ok {
-			for _, z := range y.Body {
-				a.parseStmt(z)
-			}
+			a.parseCaseClause(y)
 		}
 	}
 }
 
-// parseTypeSwitchStmt parses
+// parseTypeSwitchStmt parses type switches, i.e. `switch x := y.(type) {}`
 func (a *analyzer) parseTypeSwitchStmt(in *ast.TypeSwitchStmt) {
+	if in.Init != nil {
+		a.parseStmt(in.Init)
+	}
+	if in.Assign != nil {
+		a.parseStmt(in.Assign)
+	}
 	if in.Body != nil {
 		for _, x := range in.Body.List {
 			if y, ok := x.(*ast.CaseClause); ok {
-				for _, z := range y.Body {
-					a.parseStmt(z)
-				}
+				a.parseCaseClause(y)
 			}
 		}
 	}
 }
 
+// parseIfStmt parses an if statement, including its init statement, condition, and else branch.
+func (a *analyzer) parseIfStmt(in *ast.IfStmt) {
+	if in.Init != nil {
+		a.parseStmt(in.Init)
+	}
+	a.parseNestedExpr(in.Cond)
+	a.parseBlockStmt(in.Body)
+	if in.Else != nil {
+		a.parseStmt(in.Else)
+	}
+}
+
+// parseForStmt parses a for loop, including its init statement, condition, and post statement.
+func (a *analyzer) parseForStmt(in *ast.ForStmt) {
+	if in.Init != nil {
+		a.parseStmt(in.Init)
+	}
+	a.parseNestedExpr(in.Cond)
+	if in.Post != nil {
+		a.parseStmt(in.Post)
+	}
+	a.parseBlockStmt(in.Body)
+}
+
+// parseRangeStmt parses a range loop, including the expression being ranged over.
+func (a *analyzer) parseRangeStmt(in *ast.RangeStmt) {
+	a.parseNestedExpr(in.X)
+	a.parseBlockStmt(in.Body)
+}
+
 // parseStmt parses a statement. From the go/ast docs:
 // 		All statement nodes implement the Stmt interface.
-// Cases we don't handle:
-//		BadStmt - we only parse valid code
-//		BlockStmt (sort of, we iterate over these in the form of `x.Body.List`)
-//		these are simply unnecessary:
-//			BranchStmt  (if/else/case)
-//			EmptyStmt   ()
-//			IncDeclStmt (++/--)
-//			LabeledStmt (sorta-kinda-not-goto)
+// Every statement in the Go grammar is handled, with the exception of a few that can't contain calls:
+//		BadStmt    - we only parse valid code
+//		BranchStmt (break/continue/goto/fallthrough)
+//		EmptyStmt  ()
 func (a *analyzer) parseStmt(in ast.Stmt) {
 	switch e := in.(type) {
 	case *ast.AssignStmt: // handles things like `e := Example{}` (with or without &)
 		a.parseAssignStmt(e)
-	// NOTE: even though RangeStmt/IfStmt/ForStmt are handled identically, Go will (rightfully) complain when trying
-	// to use a multiple case statement (i.e. `case *ast.RangeStmt, *ast.IfStmt`), so we're doing it this way.
 	case *ast.RangeStmt:
-		for _, x := range e.Body.List {
-			a.parseStmt(x)
-		}
+		a.parseRangeStmt(e)
 	case *ast.IfStmt:
-		for _, x := range e.Body.List {
-			a.parseStmt(x)
-		}
+		a.parseIfStmt(e)
 	case *ast.ForStmt:
-		for _, x := range e.Body.List {
-			a.parseStmt(x)
-		}
+		a.parseForStmt(e)
+	case *ast.BlockStmt:
+		a.parseBlockStmt(e)
+	case *ast.LabeledStmt:
+		a.parseStmt(e.Stmt)
+	case *ast.IncDecStmt:
+		a.parseNestedExpr(e.X)
 	case *ast.DeclStmt:
 		a.parseDeclStmt(e)
 	case *ast.ExprStmt:
 		a.parseExprStmt(e)
 	case *ast.DeferStmt:
-		a.parseExpr(e.Call.Fun)
+		a.parseCallExpr(e.Call)
 	case *ast.GoStmt:
-		a.parseExpr(e.Call.Fun)
+		a.parseCallExpr(e.Call)
 	case *ast.ReturnStmt:
 		a.parseReturnStmt(e)
 	case *ast.SelectStmt:
@@ -346,11 +791,26 @@ func (a *analyzer) parseStmt(in ast.Stmt) {
 	}
 }
 
-func (a *analyzer) getDeclaredNames(in *ast.File) {
+// getDeclaredNames records the functions declared in a given file, leaving out:
+//   - the ones the include and exclude patterns rule out,
+//   - the ones outside of the changes in the options, if there are any,
+//   - the ones a suppression (directive or rule) that hasn't expired yet applies to,
+//   - every function in a generated file unless the options say otherwise, which are only counted.
+func (a *analyzer) getDeclaredNames(in *ast.File) error {
+	generated := !a.options.IncludeGenerated && ast.IsGenerated(in)
+
+	fileSuppression, err := a.findSuppression(ignoreFileDirective, headerComments(in)...)
+	if err != nil {
+		return err
+	}
+
 	for _, d := range in.Decls {
 		if f, ok := d.(*ast.FuncDecl); ok {
 			declPos := a.fileset.Position(f.Type.Func)
 			functionName := a.parseFuncDecl(f)
+			if !a.included(functionName, declPos.Filename) {
+				continue
+			}
 
 			tf := BlanketFunc{
 				Name:     functionName,
@@ -359,26 +819,176 @@ func (a *analyzer) getDeclaredNames(in *ast.File) {
 			}
 
 			if f.Body != nil {
-				tf.RBracePos = a.fileset.Position(f.Body.Lbrace)
-				tf.LBracePos = a.fileset.Position(f.Body.Rbrace)
+				tf.LBracePos = a.fileset.Position(f.Body.Lbrace)
+				tf.RBracePos = a.fileset.Position(f.Body.Rbrace)
+			}
+
+			if !a.changed(tf) {
+				continue
+			}
+			if generated {
+				a.generatedFuncs.Add(functionName)
+				continue
+			}
+
+			suppression, err := a.findSuppression(ignoreDirective, f.Doc)
+			if err != nil {
+				return err
+			}
+			if suppression == nil {
+				suppression = fileSuppression
+			}
+			if suppression == nil {
+				suppression = a.suppressionRuleFor(functionName, declPos.Filename)
+			}
+
+			if suppression != nil && !suppression.expired(a.now()) {
+				a.suppressedFuncs[functionName] = SuppressedFunc{BlanketFunc: tf, Suppression: *suppression}
+				continue
+			}
+			if suppression != nil && a.debug {
+				log.Printf("suppression of %s expired on %s", functionName, suppression.Until.Format(untilLayout))
 			}
 			a.declaredFuncInfo[functionName] = tf
 		}
 	}
+	return nil
+}
+
+// findImportName returns the name a given file uses to refer to the package under analysis, or an empty string
+// if the file doesn't import it (which is the case for every file except those in external test packages).
+func (a *analyzer) findImportName(in *ast.File) string {
+	if a.importPath == "" {
+		return ""
+	}
+
+	for _, imp := range in.Imports {
+		if path, err := strconv.Unquote(imp.Path.Value); err != nil || path != a.importPath {
+			continue
+		}
+		if imp.Name != nil {
+			if imp.Name.Name == "_" || imp.Name.Name == "." {
+				return ""
+			}
+			return imp.Name.Name
+		}
+		return a.packageName
+	}
+	return ""
+}
+
+// getCalledNames records the calls reachable from the test entry points (Test*, Benchmark*, Example*, Fuzz*
+// and TestMain) declared in a given set of test files. Calls made by the other functions in those files are
+// only recorded when a test uses them, and are attributed to the test that does. Calls made by test helpers
+// (and anything the helpers call in turn) are told apart from the ones made by the test itself.
+func (a *analyzer) getCalledNames(files ...*ast.File) {
+	var entryPoints []string
+	for _, f := range files {
+		for _, d := range f.Decls {
+			if n, ok := d.(*ast.FuncDecl); ok && n.Body != nil {
+				key := testFuncKey(f.Name.Name, a.parseFuncDecl(n))
+				a.testFuncs[key] = testFunc{decl: n, file: f}
+				if isTestEntryPoint(n) {
+					entryPoints = append(entryPoints, key)
+				} else if a.isTestHelper(a.testFuncs[key]) {
+					a.testHelpers.Add(key)
+				}
+			}
+		}
+	}
+
+	// package level values, like test tables, are parsed as if they were part of a test body.
+	for _, f := range files {
+		a.enterTestFile(f)
+		for _, d := range f.Decls {
+			if n, ok := d.(*ast.GenDecl); ok {
+				a.parseGenDecl(n)
+			}
+		}
+	}
+	a.followReachedTestFuncs()
+
+	// every test is followed on its own, so that the calls its helpers make are attributed to it.
+	for _, key := range entryPoints {
+		fn := a.testFuncs[key]
+		a.currentTest = a.parseFuncDecl(fn.decl)
+		a.parseTestFunc(fn)
+		a.followReachedTestFuncs()
+	}
+	a.currentTest = ""
+}
+
+// followReachedTestFuncs parses the functions declared in the test files that have been reached so far. They're
+// parsed breadth first, so the first time we reach one is also the closest it gets to a test. Calls made in them
+// are attributed to the build category of the test that reached them, rather than that of the file they're in.
+func (a *analyzer) followReachedTestFuncs() {
+	visited := set.New()
+	for len(a.reachedTestFuncs) > 0 {
+		reached := a.reachedTestFuncs[0]
+		a.reachedTestFuncs = a.reachedTestFuncs[1:]
+		fn := a.testFuncs[reached.key]
+		if visited.Has(reached.key) || isTestEntryPoint(fn.decl) {
+			continue
+		}
+		visited.Add(reached.key)
+
+		a.enterTestFile(fn.file)
+		a.testCategory = reached.category
+		a.viaHelper = reached.viaHelper
+		a.callDepth = reached.depth
+		for _, le := range fn.decl.Body.List {
+			a.parseStmt(le)
+		}
+	}
+	a.viaHelper = false
+	a.callDepth = 0
 }
 
-func (a *analyzer) getCalledNames(in *ast.File) {
+// findProductionCalls records the functions each function declared in a given non-test file calls.
+func (a *analyzer) findProductionCalls(in *ast.File) {
+	a.importedAs = ""
+	a.testCategory = ""
+	a.testPackage = ""
 	for _, d := range in.Decls {
-		switch n := d.(type) {
-		case *ast.GenDecl:
-			a.parseGenDecl(n)
-		case *ast.FuncDecl:
-			if _, ok := a.helperFunctionReturnMap[n.Name.Name]; !ok && n.Body != nil {
-				for _, le := range n.Body.List {
-					a.parseStmt(le)
+		n, ok := d.(*ast.FuncDecl)
+		if !ok || n.Body == nil {
+			continue
+		}
+
+		// give the receiver and parameters their types, so calls of their methods can be resolved.
+		fields := n.Type.Params.List
+		if n.Recv != nil {
+			fields = append(append([]*ast.Field{}, n.Recv.List...), fields...)
+		}
+		for _, field := range fields {
+			for _, name := range field.Names {
+				if typeName := typeExprName(field.Type); typeName != "" {
+					a.nameToTypeMap[name.Name] = typeName
 				}
 			}
 		}
+
+		a.callSink = set.New()
+		for _, le := range n.Body.List {
+			a.parseStmt(le)
+		}
+		a.productionCalls[a.parseFuncDecl(n)] = a.callSink
+		a.callSink = nil
+	}
+}
+
+// enterTestFile sets up the per-file state used while parsing the declarations of a given test file.
+func (a *analyzer) enterTestFile(in *ast.File) {
+	a.importedAs = a.findImportName(in)
+	a.testCategory = buildCategory(in)
+	a.testPackage = in.Name.Name
+}
+
+// parseTestFunc parses the body of a function declared in a test file.
+func (a *analyzer) parseTestFunc(fn testFunc) {
+	a.enterTestFile(fn.file)
+	for _, le := range fn.decl.Body.List {
+		a.parseStmt(le)
 	}
 }
 
@@ -387,7 +997,78 @@ func (a *analyzer) getCalledNames(in *ast.File) {
 // 	return a.fileset.Position(p)
 // }
 
+// typeExprName returns the name of the type a given type expression refers to, ignoring
+// pointers, package qualifiers and type arguments (the same way nameToTypeMap entries are
+// recorded). So `*pkg.Stack[int]` becomes `Stack`.
+func typeExprName(in ast.Expr) string {
+	switch t := in.(type) {
+	case *ast.Ident:
+		return t.Name
+	case *ast.StarExpr:
+		return typeExprName(t.X)
+	case *ast.SelectorExpr:
+		return t.Sel.Name
+	case *ast.IndexExpr:
+		return typeExprName(t.X)
+	case *ast.IndexListExpr:
+		return typeExprName(t.X)
+	}
+	return ""
+}
+
+// parseStructType records the field types of a given struct type. Fields that are themselves
+// anonymous structs are recorded under a name derived from their parent (i.e. `Parent.field`),
+// so that chains running through them can still be resolved.
+func (a *analyzer) parseStructType(typeName string, in *ast.StructType) {
+	if _, ok := a.structFieldMap[typeName]; !ok {
+		a.structFieldMap[typeName] = map[string]string{}
+	}
+
+	for _, field := range in.Fields.List {
+		if len(field.Names) == 0 {
+			// an embedded field is named after its type, and promotes that type's fields and methods.
+			if embedded := typeExprName(field.Type); embedded != "" {
+				a.structFieldMap[typeName][embedded] = embedded
+				a.embeddedTypeMap[typeName] = append(a.embeddedTypeMap[typeName], embedded)
+			}
+		}
+		for _, name := range field.Names {
+			fieldType := typeExprName(field.Type)
+			if st, ok := field.Type.(*ast.StructType); ok {
+				fieldType = fmt.Sprintf("%s.%s", typeName, name.Name)
+				a.parseStructType(fieldType, st)
+			}
+			if fieldType != "" {
+				a.structFieldMap[typeName][name.Name] = fieldType
+			}
+		}
+	}
+}
+
+// findStructTypes records the fields of every struct type declared in a given file, as well as
+// the names of the interface types it declares.
+func (a *analyzer) findStructTypes(in *ast.File) {
+	for _, d := range in.Decls {
+		if gd, ok := d.(*ast.GenDecl); ok && gd.Tok == token.TYPE {
+			for _, spec := range gd.Specs {
+				if ts, ok := spec.(*ast.TypeSpec); ok {
+					switch t := ts.Type.(type) {
+					case *ast.StructType:
+						a.parseStructType(ts.Name.Name, t)
+					case *ast.InterfaceType:
+						a.interfaceTypes[ts.Name.Name] = true
+					}
+				}
+			}
+		}
+	}
+}
+
+// findHelperFuncs records the return types of the functions declared in a given file, so that the types of the
+// variables their results are assigned to are known. Despite the name, this says nothing about which functions
+// are test helpers; see isTestHelper for that.
 func (a *analyzer) findHelperFuncs(in *ast.File) {
+	a.importedAs = a.findImportName(in)
 	for _, d := range in.Decls {
 		if n, ok := d.(*ast.FuncDecl); ok {
 			functionName := n.Name.Name
@@ -399,26 +1080,34 @@ func (a *analyzer) findHelperFuncs(in *ast.File) {
 }
 
 func (a *analyzer) Analyze(analyzePackage string) (*BlanketReport, error) {
-	gopath := os.Getenv("GOPATH")
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, errors.Wrap(err, "getting current working directory")
+	}
 
-	pkgDir := strings.Join([]string{gopath, "src", analyzePackage}, "/")
-	if analyzePackage == "." {
-		var err error
-		pkgDir, err = os.Getwd()
-		if err != nil {
-			return nil, errors.Wrap(err, "getting current working directory")
-		}
+	pkgs, err := listPackages(wd, a.options, analyzePackage)
+	if err != nil {
+		return nil, err
+	}
+	if len(pkgs) > 1 {
+		return nil, fmt.Errorf("%s matched %d packages, but only one package can be analyzed at a time", analyzePackage, len(pkgs))
 	}
 
+	return a.analyzeListedPackage(pkgs[0])
+}
+
+// analyzeListedPackage analyzes a package that has already been resolved by listPackages.
+func (a *analyzer) analyzeListedPackage(pkg listedPackage) (*BlanketReport, error) {
+	pkgDir := pkg.Dir
+	a.importPath = pkg.ImportPath
+	a.packageName = pkg.Name
+	a.packageDir = pkg.Dir
+
 	if a.debug {
 		log.Printf("package directory: %s", pkgDir)
 	}
 
-	if _, err := os.Stat(pkgDir); os.IsNotExist(err) {
-		return nil, fmt.Errorf("packageDir doesn't exist: %s", pkgDir)
-	}
-
-	astPkg, err := parser.ParseDir(a.fileset, pkgDir, nil, parser.AllErrors)
+	astPkg, err := a.parseListedFiles(pkg)
 	if err != nil {
 		return nil, errors.Wrap(err, "parsing package directory")
 	}
@@ -435,10 +1124,11 @@ func (a *analyzer) Analyze(analyzePackage string) (*BlanketReport, error) {
 	// writing this comment amidst a large-ish refactor in the first
 	// place, or I'd write that instead of this).
 
-	// find all helper funcs first so we have an idea of what they are.
+	// find all helper funcs and struct types first so we have an idea of what they are.
 	for _, pkg := range astPkg {
 		for _, f := range pkg.Files {
 			a.findHelperFuncs(f)
+			a.findStructTypes(f)
 		}
 	}
 
@@ -446,16 +1136,29 @@ func (a *analyzer) Analyze(analyzePackage string) (*BlanketReport, error) {
 	for _, pkg := range astPkg {
 		for name, f := range pkg.Files {
 			if !strings.HasSuffix(name, "_test.go") {
-				a.getDeclaredNames(f)
+				if err := a.getDeclaredNames(f); err != nil {
+					return nil, errors.Wrap(err, "finding declared functions")
+				}
 			}
 		}
 	}
 
 	// find all the called names
-	for _, pkg := range astPkg {
-		for name, f := range pkg.Files {
-			if strings.HasSuffix(name, "_test.go") {
-				a.getCalledNames(f)
+	if a.options.TypeCheck {
+		if err := a.typeCheck(astPkg); err != nil {
+			return nil, err
+		}
+	} else {
+		for _, pkg := range astPkg {
+			a.getCalledNames(testFilesOf(pkg)...)
+		}
+
+		// the production code is parsed last, since the variable types it records would confuse the tests.
+		for _, pkg := range astPkg {
+			for name, f := range pkg.Files {
+				if !strings.HasSuffix(name, "_test.go") {
+					a.findProductionCalls(f)
+				}
 			}
 		}
 	}
@@ -465,14 +1168,58 @@ func (a *analyzer) Analyze(analyzePackage string) (*BlanketReport, error) {
 		declaredFuncs.Add(f.Name)
 	}
 
+	a.resolvePromotedMethods(a.calledFuncs)
+	a.resolvePromotedMethods(a.interfaceCalledFuncs)
 	for _, x := range set.StringSlice(set.Difference(a.calledFuncs, declaredFuncs)) {
 		a.calledFuncs.Remove(x)
 	}
+	for _, called := range a.categoryCalledFuncs {
+		a.resolvePromotedMethods(called)
+		for _, x := range set.StringSlice(set.Difference(called, declaredFuncs)) {
+			called.Remove(x)
+		}
+	}
+	a.resolvePromotedMethods(a.directCalledFuncs)
+	a.resolvePromotedMethods(a.helperCalledFuncs)
+
+	calledBy := map[string]*set.Set{}
+	for name, tests := range a.calledBy {
+		if name = a.promotedMethodName(name); declaredFuncs.Has(name) {
+			if _, ok := calledBy[name]; !ok {
+				calledBy[name] = set.New()
+			}
+			calledBy[name].Merge(tests)
+		}
+	}
 
 	a.latestReport = &BlanketReport{
-		DeclaredDetails: a.declaredFuncInfo,
-		Declared:        declaredFuncs,
-		Called:          a.calledFuncs,
+		DeclaredDetails:  a.declaredFuncInfo,
+		Declared:         declaredFuncs,
+		Called:           a.calledFuncs,
+		CalledViaHelper:  set.New(),
+		CalledByCategory: a.categoryCalledFuncs,
+		CalledBy:         calledBy,
+		CallDepths:       a.computeCallDepths(declaredFuncs),
+		Suppressed:       a.suppressedFuncs,
+		Generated:        a.generatedFuncs,
+	}
+	for _, x := range set.StringSlice(set.Difference(a.helperCalledFuncs, a.directCalledFuncs)) {
+		if declaredFuncs.Has(x) {
+			a.latestReport.CalledViaHelper.Add(x)
+		}
+	}
+
+	if a.options.InterfaceCalls {
+		// only implementations that aren't called directly anywhere belong in their own category.
+		a.latestReport.CalledViaInterface = set.New()
+		for _, x := range set.StringSlice(set.Difference(a.interfaceCalledFuncs, a.calledFuncs)) {
+			if declaredFuncs.Has(x) {
+				a.latestReport.CalledViaInterface.Add(x)
+			}
+		}
+		if a.options.CountInterfaceCalls {
+			a.calledFuncs.Merge(a.latestReport.CalledViaInterface)
+		}
 	}
 	return a.latestReport, nil
 }
@@ -482,12 +1229,65 @@ func NewAnalyzer() *analyzer {
 	return &analyzer{
 		fileset:                 token.NewFileSet(),
 		declaredFuncInfo:        map[string]BlanketFunc{},
+		suppressedFuncs:         map[string]SuppressedFunc{},
+		generatedFuncs:          set.New(),
+		now:                     time.Now,
 		calledFuncs:             set.New("init"),
+		categoryCalledFuncs:     map[string]*set.Set{},
+		directCalledFuncs:       set.New("init"),
+		helperCalledFuncs:       set.New(),
+		testHelpers:             set.New(),
+		calledBy:                map[string]*set.Set{},
+		callDepths:              map[string]int{},
+		productionCalls:         map[string]*set.Set{},
+		testFuncs:               map[string]testFunc{},
 		helperFunctionReturnMap: map[string][]string{},
 		nameToTypeMap:           map[string]string{},
+		structFieldMap:          map[string]map[string]string{},
+		embeddedTypeMap:         map[string][]string{},
+		interfaceTypes:          map[string]bool{},
+		interfaceImpls:          map[string][]string{},
+		interfaceCalledFuncs:    set.New(),
 	}
 }
 
+// SetOptions configures how the analyzer behaves on subsequent calls to Analyze.
+func (a *analyzer) SetOptions(opts Options) {
+	if opts.CountInterfaceCalls {
+		opts.InterfaceCalls = true
+	}
+	a.options = opts
+}
+
+// calledOnlyBy reports whether a given function is called by tests in a given category and no other.
+func (a *analyzer) calledOnlyBy(category, name string) bool {
+	for other, called := range a.latestReport.CalledByCategory {
+		if other != category && called.Has(name) {
+			return false
+		}
+	}
+	return true
+}
+
+// groupByFilename returns the declarations of a given set of functions grouped by the file they're declared in
+// and sorted by line, keeping track of the longest function name along the way.
+func (a *analyzer) groupByFilename(names []string, longestFunctionNameLength *int) map[string][]BlanketFunc {
+	funcs := &blanketDetails{}
+	for _, s := range names {
+		if utf8.RuneCountInString(s) > *longestFunctionNameLength {
+			*longestFunctionNameLength = len(s)
+		}
+		*funcs = append(*funcs, a.declaredFuncInfo[s])
+	}
+
+	sort.Sort(funcs)
+	byFilename := map[string][]BlanketFunc{}
+	for _, tf := range *funcs {
+		byFilename[tf.Filename] = append(byFilename[tf.Filename], tf)
+	}
+	return byFilename
+}
+
 func (a *analyzer) GenerateDiffReport() *blanketOutput {
 	if a.latestReport == nil {
 		return nil
@@ -497,26 +1297,65 @@ func (a *analyzer) GenerateDiffReport() *blanketOutput {
 	declaredFuncCount := a.latestReport.Declared.Size()
 	calledFuncCount := a.latestReport.Called.Size()
 	longestFunctionNameLength := 0
-	missingFuncs := &blanketDetails{}
-	for _, s := range diff {
-		if utf8.RuneCountInString(s) > longestFunctionNameLength {
-			longestFunctionNameLength = len(s)
-		}
-		*missingFuncs = append(*missingFuncs, a.declaredFuncInfo[s])
+	byFilename := a.groupByFilename(diff, &longestFunctionNameLength)
+
+	var viaInterface map[string][]BlanketFunc
+	viaInterfaceCount := 0
+	if a.latestReport.CalledViaInterface != nil {
+		viaInterfaceCount = a.latestReport.CalledViaInterface.Size()
+		viaInterface = a.groupByFilename(set.StringSlice(a.latestReport.CalledViaInterface), &longestFunctionNameLength)
 	}
 
-	sort.Sort(missingFuncs)
-	byFilename := map[string][]BlanketFunc{}
-	for _, tf := range *missingFuncs {
-		byFilename[tf.Filename] = append(byFilename[tf.Filename], tf)
+	generatedCount := 0
+	if a.latestReport.Generated != nil {
+		generatedCount = a.latestReport.Generated.Size()
+	}
+
+	var viaHelper map[string][]BlanketFunc
+	viaHelperCount := 0
+	if a.latestReport.CalledViaHelper != nil && !a.latestReport.CalledViaHelper.IsEmpty() {
+		viaHelperCount = a.latestReport.CalledViaHelper.Size()
+		viaHelper = a.groupByFilename(set.StringSlice(a.latestReport.CalledViaHelper), &longestFunctionNameLength)
+	}
+
+	var categories map[string]*categoryOutput
+	for category, called := range a.latestReport.CalledByCategory {
+		if categories == nil {
+			categories = map[string]*categoryOutput{}
+		}
+		var only []string
+		for _, name := range set.StringSlice(called) {
+			// with a single category, "only tested here" would just repeat every called function.
+			if len(a.latestReport.CalledByCategory) > 1 && a.calledOnlyBy(category, name) {
+				only = append(only, name)
+			}
+		}
+		sort.Strings(only)
+
+		categories[category] = &categoryOutput{
+			CalledCount: called.Size(),
+			Score:       calculateScore(called.Size(), declaredFuncCount),
+			Only:        only,
+		}
 	}
-	score := float64(calledFuncCount) / float64(declaredFuncCount)
 
 	return &blanketOutput{
+		Package:                   a.importPath,
+		Dir:                       a.packageDir,
 		DeclaredCount:             declaredFuncCount,
 		CalledCount:               calledFuncCount,
-		Score:                     int(score * 100),
+		Score:                     calculateScore(calledFuncCount, declaredFuncCount),
+		Scope:                     a.options.Scope,
+		ViaInterfaceCount:         viaInterfaceCount,
+		ViaHelperCount:            viaHelperCount,
+		Categories:                categories,
 		Details:                   byFilename,
+		ViaInterface:              viaInterface,
+		ViaHelper:                 viaHelper,
+		Depths:                    a.latestReport.CallDepths,
+		Suppressed:                suppressedOutputs(a.latestReport.Suppressed),
+		GeneratedCount:            generatedCount,
 		LongestFunctionNameLength: longestFunctionNameLength,
+		Functions:                 a.functionOutputs(),
 	}
 }
@@ -5,6 +5,7 @@ import (
 	"go/ast"
 	"go/parser"
 	"go/token"
+	"go/types"
 	"log"
 	"testing"
 
@@ -21,7 +22,7 @@ import (
 ////////////////////////////////////////////////////////
 
 func parseChunkOfCode(t *testing.T, chunkOfCode string) *ast.File {
-	p, err := parser.ParseFile(token.NewFileSet(), "example.go", chunkOfCode, parser.AllErrors)
+	p, err := parser.ParseFile(token.NewFileSet(), "example.go", chunkOfCode, parser.AllErrors|parser.ParseComments)
 	if err != nil {
 		log.Println(err)
 		t.FailNow()
@@ -79,6 +80,72 @@ func TestParseExpr(t *testing.T) {
 		assert.Equal(t, expected, analyzer.calledFuncs, "expected function name to be added to output")
 	})
 
+	t.Run("package-qualified", func(_t *testing.T) {
+		analyzer := NewAnalyzer()
+		analyzer.importedAs = "example"
+
+		codeSample := `
+			package main_test
+
+			func main() {
+				example.FunctionCall()
+			}
+		`
+
+		p := parseChunkOfCode(t, codeSample)
+		input := p.Decls[0].(*ast.FuncDecl).Body.List[0].(*ast.ExprStmt).X.(*ast.CallExpr).Fun
+		expected := set.New("init", "FunctionCall")
+
+		analyzer.parseExpr(input)
+
+		assert.Equal(t, expected, analyzer.calledFuncs, "expected function name to be added to output")
+	})
+
+	t.Run("nested selector", func(_t *testing.T) {
+		analyzer := NewAnalyzer()
+		analyzer.nameToTypeMap["x"] = "Outer"
+		analyzer.structFieldMap["Outer"] = map[string]string{"First": "Middle"}
+		analyzer.structFieldMap["Middle"] = map[string]string{"Second": "Example"}
+
+		codeSample := `
+			package main
+
+			func main() {
+				x.First.Second.methodCall()
+			}
+		`
+
+		p := parseChunkOfCode(t, codeSample)
+		input := p.Decls[0].(*ast.FuncDecl).Body.List[0].(*ast.ExprStmt).X.(*ast.CallExpr).Fun
+		expected := set.New("init", "Example.methodCall")
+
+		analyzer.parseExpr(input)
+
+		assert.Equal(t, expected, analyzer.calledFuncs, "expected function name to be added to output")
+	})
+
+	t.Run("generic instantiation", func(_t *testing.T) {
+		analyzer := NewAnalyzer()
+
+		codeSample := `
+			package main
+
+			func main() {
+				NewStack[int]()
+				Map[int, string]()
+			}
+		`
+
+		p := parseChunkOfCode(t, codeSample)
+		expected := set.New("init", "NewStack", "Map")
+
+		for _, stmt := range p.Decls[0].(*ast.FuncDecl).Body.List {
+			analyzer.parseExpr(stmt.(*ast.ExprStmt).X.(*ast.CallExpr).Fun)
+		}
+
+		assert.Equal(t, expected, analyzer.calledFuncs, "expected function name to be added to output")
+	})
+
 	t.Run("function literal", func(_t *testing.T) {
 		analyzer := NewAnalyzer()
 
@@ -102,6 +169,32 @@ func TestParseExpr(t *testing.T) {
 	})
 }
 
+func TestResolveExprType(t *testing.T) {
+	analyzer := NewAnalyzer()
+	analyzer.nameToTypeMap["x"] = "Outer"
+	analyzer.structFieldMap["Outer"] = map[string]string{"First": "Middle"}
+	analyzer.structFieldMap["Middle"] = map[string]string{"Second": "Example"}
+
+	codeSample := `
+		package main
+
+		func main() {
+			_ = x
+			_ = x.First
+			_ = (*x.First).Second
+			_ = x.Nope.Second
+			_ = y.First
+		}
+	`
+
+	p := parseChunkOfCode(t, codeSample)
+	expected := []string{"Outer", "Middle", "Example", "", ""}
+	for i, stmt := range p.Decls[0].(*ast.FuncDecl).Body.List {
+		input := stmt.(*ast.AssignStmt).Rhs[0]
+		assert.Equal(t, expected[i], analyzer.resolveExprType(input), "expected type name to match")
+	}
+}
+
 func TestParseCallExpr(t *testing.T) {
 	t.Run("with ast.Ident", func(_t *testing.T) {
 		analyzer := NewAnalyzer()
@@ -195,6 +288,282 @@ func TestParseCallExpr(t *testing.T) {
 	})
 }
 
+func TestCalledFunctionName(t *testing.T) {
+	codeSample := `
+		package main
+
+		func main() {
+			A()
+			pkg.B()
+			C[int]()
+			pkg.D[string, int]()
+			func() {}()
+		}
+	`
+
+	p := parseChunkOfCode(t, codeSample)
+	expected := []string{"A", "B", "C", "D", ""}
+	for i, stmt := range p.Decls[0].(*ast.FuncDecl).Body.List {
+		actual := calledFunctionName(stmt.(*ast.ExprStmt).X.(*ast.CallExpr))
+		assert.Equal(t, expected[i], actual, "actual output does not match expected output")
+	}
+}
+
+func TestParseFuncValue(t *testing.T) {
+	codeSample := `
+		package main
+
+		func main() {
+			run(t, Parse, x.method, variable)
+		}
+	`
+
+	p := parseChunkOfCode(t, codeSample)
+	input := p.Decls[0].(*ast.FuncDecl).Body.List[0].(*ast.ExprStmt).X.(*ast.CallExpr)
+
+	t.Run("normal", func(_t *testing.T) {
+		analyzer := NewAnalyzer()
+		analyzer.declaredFuncInfo["Parse"] = BlanketFunc{Name: "Parse"}
+		analyzer.nameToTypeMap["x"] = "Example"
+
+		expected := set.New("init", "run", "Parse", "Example.method")
+		analyzer.parseCallExpr(input)
+
+		assert.Equal(t, expected, analyzer.calledFuncs, "expected function values to be added to output")
+	})
+
+	t.Run("ignoring function values", func(_t *testing.T) {
+		analyzer := NewAnalyzer()
+		analyzer.SetOptions(Options{IgnoreFuncValues: true})
+		analyzer.declaredFuncInfo["Parse"] = BlanketFunc{Name: "Parse"}
+		analyzer.nameToTypeMap["x"] = "Example"
+
+		expected := set.New("init", "run")
+		analyzer.parseCallExpr(input)
+
+		assert.Equal(t, expected, analyzer.calledFuncs, "expected function values to be left out of output")
+	})
+
+	t.Run("each value on its own", func(_t *testing.T) {
+		analyzer := NewAnalyzer()
+		analyzer.declaredFuncInfo["Parse"] = BlanketFunc{Name: "Parse"}
+		analyzer.nameToTypeMap["x"] = "Example"
+
+		expected := set.New("init", "Parse", "Example.method")
+		for _, arg := range input.Args {
+			analyzer.parseFuncValue(arg)
+		}
+
+		assert.Equal(t, expected, analyzer.calledFuncs, "expected only known functions and methods to be added to output")
+	})
+}
+
+func TestParseValueExpr(t *testing.T) {
+	codeSample := `
+		package main
+
+		func main() {
+			_, _, _, _, _, _ = A(), func() { B() }, []Thing{{fn: C}}, &D, (E), F() + 1
+		}
+	`
+
+	p := parseChunkOfCode(t, codeSample)
+	input := p.Decls[0].(*ast.FuncDecl).Body.List[0].(*ast.AssignStmt).Rhs
+
+	analyzer := NewAnalyzer()
+	for _, name := range []string{"C", "D", "E"} {
+		analyzer.declaredFuncInfo[name] = BlanketFunc{Name: name}
+	}
+
+	expected := set.New("init", "A", "B", "C", "D", "E", "F")
+	for _, x := range input {
+		analyzer.parseValueExpr(x)
+	}
+
+	assert.Equal(t, expected, analyzer.calledFuncs, "expected calls and function values to be added to output")
+}
+
+func TestParseInterfaceCall(t *testing.T) {
+	codeSample := `
+		package main
+
+		func main() {
+			var s Store = &memStore{}
+			s.Get()
+			s = diskStore{}
+			s.Get()
+		}
+	`
+
+	p := parseChunkOfCode(t, codeSample)
+	body := p.Decls[0].(*ast.FuncDecl).Body.List
+
+	t.Run("normal", func(_t *testing.T) {
+		analyzer := NewAnalyzer()
+		analyzer.SetOptions(Options{InterfaceCalls: true})
+		analyzer.interfaceTypes["Store"] = true
+		for _, stmt := range body {
+			analyzer.parseStmt(stmt)
+		}
+
+		assert.Equal(t, set.New("memStore.Get", "diskStore.Get"), analyzer.interfaceCalledFuncs, "expected interface calls to be mapped to both implementations")
+		assert.Equal(t, "Store", analyzer.nameToTypeMap["s"], "expected assignment to leave the variable's type alone")
+	})
+
+	t.Run("disabled", func(_t *testing.T) {
+		analyzer := NewAnalyzer()
+		analyzer.interfaceTypes["Store"] = true
+		for _, stmt := range body {
+			analyzer.parseStmt(stmt)
+		}
+
+		assert.Equal(t, set.New(), analyzer.interfaceCalledFuncs, "expected interface calls to be ignored")
+	})
+
+	t.Run("known implementations", func(_t *testing.T) {
+		analyzer := NewAnalyzer()
+		analyzer.interfaceTypes["Store"] = true
+		analyzer.nameToTypeMap["s"] = "Store"
+		analyzer.nameToTypeMap["x"] = "Example"
+		analyzer.interfaceImpls["s"] = []string{"memStore", "diskStore"}
+		analyzer.interfaceImpls["x"] = []string{"memStore"}
+
+		analyzer.parseInterfaceCall("s", "Get")
+		analyzer.parseInterfaceCall("x", "Get")
+
+		assert.Equal(t, set.New("memStore.Get", "diskStore.Get"), analyzer.interfaceCalledFuncs, "expected only calls through interface variables to be mapped")
+	})
+
+	t.Run("inside production code", func(_t *testing.T) {
+		analyzer := NewAnalyzer()
+		analyzer.interfaceTypes["Store"] = true
+		analyzer.nameToTypeMap["s"] = "Store"
+		analyzer.interfaceImpls["s"] = []string{"memStore"}
+		analyzer.callSink = set.New()
+
+		analyzer.parseInterfaceCall("s", "Get")
+
+		assert.Equal(t, set.New(), analyzer.interfaceCalledFuncs, "expected calls made outside of tests to be ignored")
+	})
+}
+
+func TestRecordInterfaceImpl(t *testing.T) {
+	codeSample := `
+		package main
+
+		func main() {
+			_, _, _ = &memStore{}, diskStore{}, 42
+		}
+	`
+
+	p := parseChunkOfCode(t, codeSample)
+	input := p.Decls[0].(*ast.FuncDecl).Body.List[0].(*ast.AssignStmt).Rhs
+
+	t.Run("normal", func(_t *testing.T) {
+		analyzer := NewAnalyzer()
+		analyzer.SetOptions(Options{InterfaceCalls: true})
+		analyzer.interfaceTypes["Store"] = true
+		analyzer.nameToTypeMap["s"] = "Store"
+		for _, x := range input {
+			analyzer.recordInterfaceImpl("s", x)
+		}
+
+		assert.Equal(t, []string{"memStore", "diskStore"}, analyzer.interfaceImpls["s"], "expected concrete types to be recorded")
+	})
+
+	t.Run("not an interface", func(_t *testing.T) {
+		analyzer := NewAnalyzer()
+		analyzer.SetOptions(Options{InterfaceCalls: true})
+		analyzer.nameToTypeMap["s"] = "Example"
+		analyzer.recordInterfaceImpl("s", input[0])
+
+		assert.Empty(t, analyzer.interfaceImpls, "expected variables of other types to be ignored")
+	})
+
+	t.Run("disabled", func(_t *testing.T) {
+		analyzer := NewAnalyzer()
+		analyzer.interfaceTypes["Store"] = true
+		analyzer.nameToTypeMap["s"] = "Store"
+		analyzer.recordInterfaceImpl("s", input[0])
+
+		assert.Empty(t, analyzer.interfaceImpls, "expected implementations to be ignored")
+	})
+}
+
+func TestConcreteTypeOf(t *testing.T) {
+	analyzer := NewAnalyzer()
+	analyzer.interfaceTypes["Store"] = true
+	analyzer.helperFunctionReturnMap["newStore"] = []string{"memStore"}
+	analyzer.nameToTypeMap["other"] = "Store"
+
+	tests := map[string]string{
+		"&memStore{}": "memStore",
+		"diskStore{}": "diskStore",
+		"newStore()":  "memStore",
+		"other":       "",
+	}
+	for input, expected := range tests {
+		expr, err := parser.ParseExpr(input)
+		if err != nil {
+			t.Logf("failing because ParseExpr returned error: %v", err)
+			t.FailNow()
+		}
+		assert.Equal(t, expected, analyzer.concreteTypeOf(expr), "unexpected concrete type for %s", input)
+	}
+}
+
+func TestBuildCategory(t *testing.T) {
+	tests := map[string]string{
+		"package main\n": unitTestCategory,
+		"//go:build integration\n\npackage main\n":                          "integration",
+		"// Copyright\n\n//go:build integration && !race\n\npackage main\n": "integration && !race",
+		"package main\n\n//go:build integration\n":                          unitTestCategory,
+	}
+	for input, expected := range tests {
+		assert.Equal(t, expected, buildCategory(parseChunkOfCode(t, input)), "unexpected category for %q", input)
+	}
+}
+
+func TestMarkCalled(t *testing.T) {
+	analyzer := NewAnalyzer()
+	analyzer.markCalled("A")
+	analyzer.testCategory = "integration"
+	analyzer.markCalled("B")
+
+	assert.Equal(t, set.New("init", "A", "B"), analyzer.calledFuncs)
+	assert.Equal(t, map[string]*set.Set{"integration": set.New("B")}, analyzer.categoryCalledFuncs)
+}
+
+func TestParseCompositeElts(t *testing.T) {
+	analyzer := NewAnalyzer()
+	analyzer.declaredFuncInfo["A"] = BlanketFunc{Name: "A"}
+	analyzer.declaredFuncInfo["B"] = BlanketFunc{Name: "B"}
+
+	codeSample := `
+		package main
+
+		func main() {
+			tests := []struct{
+				name string
+				fn   func()
+			}{
+				{name: "a", fn: A},
+				{"b", B},
+				{name: "c", fn: func() { C() }},
+				{name: "d", fn: D()},
+			}
+		}
+	`
+
+	p := parseChunkOfCode(t, codeSample)
+	input := p.Decls[0].(*ast.FuncDecl).Body.List[0].(*ast.AssignStmt).Rhs[0].(*ast.CompositeLit)
+	expected := set.New("init", "A", "B", "C", "D")
+
+	analyzer.parseCompositeElts(input)
+
+	assert.Equal(t, expected, analyzer.calledFuncs, "expected nested function values and calls to be added to output")
+}
+
 func TestParseUnaryExpr(t *testing.T) {
 	analyzer := NewAnalyzer()
 
@@ -394,6 +763,81 @@ func TestParseFuncDecl(t *testing.T) {
 
 		assert.Equal(t, expected, actual, "actual output does not match expected output")
 	})
+
+	t.Run("with generic receivers", func(_t *testing.T) {
+		analyzer := NewAnalyzer()
+
+		codeSample := `
+			package test
+			type Stack[T any] struct{}
+			func (s *Stack[T]) Push(v T){}
+			func (s Stack[T]) Len() int { return 0 }
+			type Pair[K comparable, V any] struct{}
+			func (p Pair[K, V]) Key() K { var k K; return k }
+			func (p *Pair[K, V]) Value() V { var v V; return v }
+		`
+
+		p := parseChunkOfCode(t, codeSample)
+		expected := map[int]string{
+			1: "Stack.Push",
+			2: "Stack.Len",
+			4: "Pair.Key",
+			5: "Pair.Value",
+		}
+
+		for i, name := range expected {
+			assert.Equal(t, name, analyzer.parseFuncDecl(p.Decls[i].(*ast.FuncDecl)), "actual output does not match expected output")
+		}
+	})
+
+	t.Run("with receiver lacking an object", func(_t *testing.T) {
+		analyzer := NewAnalyzer()
+
+		input := &ast.FuncDecl{
+			Name: ast.NewIdent("method"),
+			Recv: &ast.FieldList{List: []*ast.Field{{Type: ast.NewIdent("Example")}}},
+		}
+
+		assert.Equal(t, "Example.method", analyzer.parseFuncDecl(input), "actual output does not match expected output")
+	})
+}
+
+func TestTypeExprName(t *testing.T) {
+	codeSample := `
+		package test
+		var (
+			a Example
+			b *Example
+			c pkg.Example
+			d Stack[int]
+			e *pkg.Pair[string, int]
+			f []Example
+		)
+	`
+
+	p := parseChunkOfCode(t, codeSample)
+	expected := []string{"Example", "Example", "Example", "Stack", "Pair", ""}
+	for i, spec := range p.Decls[0].(*ast.GenDecl).Specs {
+		assert.Equal(t, expected[i], typeExprName(spec.(*ast.ValueSpec).Type), "actual output does not match expected output")
+	}
+}
+
+func TestStripTypeArgs(t *testing.T) {
+	codeSample := `
+		package test
+		var (
+			a Stack[int]
+			b pkg.Pair[string, int]
+			c Example
+		)
+	`
+
+	p := parseChunkOfCode(t, codeSample)
+	expected := []string{"Stack", "pkg.Pair", "Example"}
+	for i, spec := range p.Decls[0].(*ast.GenDecl).Specs {
+		actual := stripTypeArgs(spec.(*ast.ValueSpec).Type)
+		assert.Equal(t, expected[i], types.ExprString(actual), "actual output does not match expected output")
+	}
 }
 
 func TestParseAssignStmt(t *testing.T) {
@@ -579,34 +1023,115 @@ func TestParseAssignStmt(t *testing.T) {
 
 		assert.Equal(t, expected, analyzer.nameToTypeMap, "actual output does not match expected output")
 	})
-}
 
-func TestParseHelperSelectorExpr(t *testing.T) {
-	analyzer := NewAnalyzer()
+	t.Run("selector expression", func(_t *testing.T) {
+		analyzer := NewAnalyzer()
+		analyzer.nameToTypeMap["s"] = "suite"
+		analyzer.structFieldMap["suite"] = map[string]string{"store": "Store"}
 
-	codeSample := `
-		package main
-		import "testing"
+		codeSample := `
+			package main
+			import "testing"
+			func TestX(t *testing.T) {
+				store := s.store
+			}
+		`
 
-		func helperGenerator(t *testing.T) (ast.SelectorExpr, error) {
-			return ast.SelectorExpr{}, nil
-		}
-	`
+		p := parseChunkOfCode(t, codeSample)
+		input := p.Decls[1].(*ast.FuncDecl).Body.List[0].(*ast.AssignStmt)
+		expected := map[string]string{"s": "suite", "store": "Store"}
 
-	p := parseChunkOfCode(t, codeSample)
-	input := p.Decls[1].(*ast.FuncDecl).Type.Results.List[0].Type.(*ast.SelectorExpr)
+		analyzer.parseAssignStmt(input)
 
-	name := "arbitraryFunctionName"
-	expected := map[string][]string{
-		name: {"ast.SelectorExpr"},
-	}
+		assert.Equal(t, expected, analyzer.nameToTypeMap, "actual output does not match expected output")
+	})
+
+	t.Run("CallExpr with fewer known return types than assigned names", func(_t *testing.T) {
+		analyzer := NewAnalyzer()
+		analyzer.helperFunctionReturnMap["example"] = []string{"X"}
+
+		codeSample := `
+			package main
+			import "testing"
+			func TestX(t *testing.T) {
+				x, err := example()
+			}
+		`
+
+		p := parseChunkOfCode(t, codeSample)
+		input := p.Decls[1].(*ast.FuncDecl).Body.List[0].(*ast.AssignStmt)
+		expected := map[string]string{"x": "X"}
+
+		analyzer.parseAssignStmt(input)
+
+		assert.Equal(t, expected, analyzer.nameToTypeMap, "actual output does not match expected output")
+	})
+}
+
+func TestParseHelperSelectorExpr(t *testing.T) {
+	analyzer := NewAnalyzer()
+
+	codeSample := `
+		package main
+		import "testing"
+
+		func helperGenerator(t *testing.T) (ast.SelectorExpr, error) {
+			return ast.SelectorExpr{}, nil
+		}
+	`
+
+	p := parseChunkOfCode(t, codeSample)
+	input := p.Decls[1].(*ast.FuncDecl).Type.Results.List[0].Type.(*ast.SelectorExpr)
+
+	name := "arbitraryFunctionName"
+	expected := map[string][]string{
+		name: {"ast.SelectorExpr"},
+	}
 
 	analyzer.parseHelperSelectorExpr(input, name)
 
 	assert.Equal(t, expected, analyzer.helperFunctionReturnMap, "expected output did not match actual output")
+
+	t.Run("package under analysis", func(_t *testing.T) {
+		analyzer := NewAnalyzer()
+		analyzer.importedAs = "ast"
+
+		expected := map[string][]string{
+			name: {"SelectorExpr"},
+		}
+
+		analyzer.parseHelperSelectorExpr(input, name)
+
+		assert.Equal(t, expected, analyzer.helperFunctionReturnMap, "expected output did not match actual output")
+	})
 }
 
 func TestParseHelperFunction(t *testing.T) {
+	t.Run("generic", func(_t *testing.T) {
+		analyzer := NewAnalyzer()
+
+		codeSample := `
+			package main
+
+			func NewStack[T any]() (*Stack[T], Pair[T, T]) {
+				return &Stack[T]{}, Pair[T, T]{}
+			}
+		`
+
+		p := parseChunkOfCode(t, codeSample)
+		input := p.Decls[0].(*ast.FuncDecl)
+
+		expected := map[string][]string{
+			"NewStack": {
+				"Stack",
+				"Pair",
+			},
+		}
+		analyzer.parseHelperFunction(input)
+
+		assert.Equal(t, expected, analyzer.helperFunctionReturnMap, "expected output did not match actual output")
+	})
+
 	t.Run("ident", func(_t *testing.T) {
 		analyzer := NewAnalyzer()
 
@@ -817,6 +1342,62 @@ func TestParseSwitchStmt(t *testing.T) {
 	assert.Equal(t, expected, analyzer.calledFuncs, "expected function name to be added to output")
 }
 
+func TestParseBlockStmt(t *testing.T) {
+	t.Run("normal", func(_t *testing.T) {
+		analyzer := NewAnalyzer()
+
+		codeSample := `
+		package main
+		func main(){
+			{
+				A()
+				{
+					B()
+				}
+			}
+		}
+	`
+
+		p := parseChunkOfCode(t, codeSample)
+		input := p.Decls[0].(*ast.FuncDecl).Body.List[0].(*ast.BlockStmt)
+		expected := set.New("init", "A", "B")
+
+		analyzer.parseBlockStmt(input)
+
+		assert.Equal(t, expected, analyzer.calledFuncs, "expected function names in nested blocks to be added to output")
+	})
+
+	t.Run("nil block", func(_t *testing.T) {
+		analyzer := NewAnalyzer()
+
+		analyzer.parseBlockStmt(nil)
+
+		assert.Equal(t, set.New("init"), analyzer.calledFuncs, "expected a missing block to be ignored")
+	})
+}
+
+func TestParseCaseClause(t *testing.T) {
+	analyzer := NewAnalyzer()
+
+	codeSample := `
+	package main
+	func main(){
+		switch {
+		case A(), B() > 0:
+			C()
+		}
+	}
+`
+
+	p := parseChunkOfCode(t, codeSample)
+	input := p.Decls[0].(*ast.FuncDecl).Body.List[0].(*ast.SwitchStmt).Body.List[0].(*ast.CaseClause)
+	expected := set.New("init", "A", "B", "C")
+
+	analyzer.parseCaseClause(input)
+
+	assert.Equal(t, expected, analyzer.calledFuncs, "expected calls in case expressions and bodies to be added to output")
+}
+
 func TestParseTypeSwitchStmt(t *testing.T) {
 	analyzer := NewAnalyzer()
 
@@ -841,6 +1422,96 @@ func TestParseTypeSwitchStmt(t *testing.T) {
 	assert.Equal(t, expected, analyzer.calledFuncs, "expected function name to be added to output")
 }
 
+func TestParseNestedExpr(t *testing.T) {
+	analyzer := NewAnalyzer()
+
+	codeSample := `
+		package main
+
+		func main() {
+			_ = A() + -B() > len(C(func() { D() })) && []int{E()}[F()] == 0
+		}
+	`
+
+	p := parseChunkOfCode(t, codeSample)
+	input := p.Decls[0].(*ast.FuncDecl).Body.List[0].(*ast.AssignStmt).Rhs[0]
+	expected := set.New("init", "A", "B", "C", "D", "E", "F", "len")
+
+	analyzer.parseNestedExpr(input)
+
+	assert.Equal(t, expected, analyzer.calledFuncs, "expected every nested call to be added to output")
+}
+
+func TestParseIfStmt(t *testing.T) {
+	analyzer := NewAnalyzer()
+
+	codeSample := `
+		package main
+
+		func main() {
+			if x := A(); B() {
+				C()
+			} else if D() {
+				E()
+			} else {
+				F()
+			}
+		}
+	`
+
+	p := parseChunkOfCode(t, codeSample)
+	input := p.Decls[0].(*ast.FuncDecl).Body.List[0].(*ast.IfStmt)
+	expected := set.New("init", "A", "B", "C", "D", "E", "F")
+
+	analyzer.parseIfStmt(input)
+
+	assert.Equal(t, expected, analyzer.calledFuncs, "expected init, condition, body and else branches to be parsed")
+}
+
+func TestParseForStmt(t *testing.T) {
+	analyzer := NewAnalyzer()
+
+	codeSample := `
+		package main
+
+		func main() {
+			for i := A(); i < B(); i += C() {
+				D()
+			}
+		}
+	`
+
+	p := parseChunkOfCode(t, codeSample)
+	input := p.Decls[0].(*ast.FuncDecl).Body.List[0].(*ast.ForStmt)
+	expected := set.New("init", "A", "B", "C", "D")
+
+	analyzer.parseForStmt(input)
+
+	assert.Equal(t, expected, analyzer.calledFuncs, "expected init, condition, post and body to be parsed")
+}
+
+func TestParseRangeStmt(t *testing.T) {
+	analyzer := NewAnalyzer()
+
+	codeSample := `
+		package main
+
+		func main() {
+			for range A() {
+				B()
+			}
+		}
+	`
+
+	p := parseChunkOfCode(t, codeSample)
+	input := p.Decls[0].(*ast.FuncDecl).Body.List[0].(*ast.RangeStmt)
+	expected := set.New("init", "A", "B")
+
+	analyzer.parseRangeStmt(input)
+
+	assert.Equal(t, expected, analyzer.calledFuncs, "expected range expression and body to be parsed")
+}
+
 func TestParseStmt(t *testing.T) {
 	analyzer := NewAnalyzer()
 
@@ -1033,6 +1704,42 @@ func TestGetDeclaredNames(t *testing.T) {
 	})
 }
 
+func TestFindImportName(t *testing.T) {
+	codeSample := `
+		package example_test
+
+		import (
+			"testing"
+
+			%s "example.com/example"
+		)
+	`
+
+	testCases := map[string]string{
+		"":   "example",
+		"ex": "ex",
+		"_":  "",
+		".":  "",
+	}
+
+	for alias, expected := range testCases {
+		analyzer := NewAnalyzer()
+		analyzer.importPath = "example.com/example"
+		analyzer.packageName = "example"
+
+		p := parseChunkOfCode(t, fmt.Sprintf(codeSample, alias))
+		assert.Equal(t, expected, analyzer.findImportName(p), "expected import name to match for alias %q", alias)
+	}
+
+	t.Run("not imported", func(_t *testing.T) {
+		analyzer := NewAnalyzer()
+		analyzer.importPath = "example.com/other"
+
+		p := parseChunkOfCode(t, fmt.Sprintf(codeSample, ""))
+		assert.Equal(t, "", analyzer.findImportName(p))
+	})
+}
+
 func TestGetCalledNames(t *testing.T) {
 	t.Run("simple", func(_t *testing.T) {
 		analyzer := NewAnalyzer()
@@ -1084,6 +1791,147 @@ func TestGetCalledNames(t *testing.T) {
 	})
 }
 
+func TestFindStructTypes(t *testing.T) {
+	analyzer := NewAnalyzer()
+
+	codeSample := `
+		package main
+
+		type Outer struct {
+			First, Other *Middle
+			Second   pkg.Example
+			embedded struct {
+				Third Example
+			}
+		}
+
+		type NotAStruct int
+	`
+
+	expected := map[string]map[string]string{
+		"Outer": {
+			"First":    "Middle",
+			"Other":    "Middle",
+			"Second":   "Example",
+			"embedded": "Outer.embedded",
+		},
+		"Outer.embedded": {
+			"Third": "Example",
+		},
+	}
+
+	analyzer.findStructTypes(parseChunkOfCode(t, codeSample))
+
+	assert.Equal(t, expected, analyzer.structFieldMap, "expected output did not match actual output")
+}
+
+func TestFindStructTypesWithEmbeddedFields(t *testing.T) {
+	analyzer := NewAnalyzer()
+
+	codeSample := `
+		package main
+
+		type Service struct {
+			*Cache
+			pkg.Logger
+			name string
+		}
+	`
+
+	analyzer.findStructTypes(parseChunkOfCode(t, codeSample))
+
+	assert.Equal(t, map[string]string{"Cache": "Cache", "Logger": "Logger", "name": "string"}, analyzer.structFieldMap["Service"])
+	assert.Equal(t, []string{"Cache", "Logger"}, analyzer.embeddedTypeMap["Service"])
+}
+
+func TestParseStructType(t *testing.T) {
+	analyzer := NewAnalyzer()
+
+	codeSample := `
+		package main
+
+		type Outer struct {
+			*Cache
+			name  string
+			inner struct {
+				deeper struct {
+					value Example
+				}
+			}
+		}
+	`
+
+	p := parseChunkOfCode(t, codeSample)
+	input := p.Decls[0].(*ast.GenDecl).Specs[0].(*ast.TypeSpec).Type.(*ast.StructType)
+	expected := map[string]map[string]string{
+		"Outer": {
+			"Cache": "Cache",
+			"name":  "string",
+			"inner": "Outer.inner",
+		},
+		"Outer.inner": {
+			"deeper": "Outer.inner.deeper",
+		},
+		"Outer.inner.deeper": {
+			"value": "Example",
+		},
+	}
+
+	analyzer.parseStructType("Outer", input)
+
+	assert.Equal(t, expected, analyzer.structFieldMap, "expected nested anonymous structs to be named after their parents")
+	assert.Equal(t, []string{"Cache"}, analyzer.embeddedTypeMap["Outer"])
+}
+
+func TestFieldType(t *testing.T) {
+	analyzer := NewAnalyzer()
+	analyzer.structFieldMap["Service"] = map[string]string{"Cache": "Cache"}
+	analyzer.structFieldMap["Cache"] = map[string]string{"size": "int"}
+	analyzer.embeddedTypeMap["Service"] = []string{"Cache"}
+	analyzer.embeddedTypeMap["node"] = []string{"node"}
+
+	t.Run("promoted field", func(_t *testing.T) {
+		assert.Equal(t, "int", analyzer.fieldType("Service", "size", set.New()))
+	})
+
+	t.Run("self embedding", func(_t *testing.T) {
+		assert.Equal(t, "", analyzer.fieldType("node", "size", set.New()))
+	})
+}
+
+func TestPromotedMethodName(t *testing.T) {
+	analyzer := NewAnalyzer()
+	analyzer.declaredFuncInfo["store.get"] = BlanketFunc{Name: "store.get"}
+	analyzer.declaredFuncInfo["Cache.Name"] = BlanketFunc{Name: "Cache.Name"}
+	analyzer.declaredFuncInfo["Service.Name"] = BlanketFunc{Name: "Service.Name"}
+	analyzer.embeddedTypeMap["Service"] = []string{"Cache"}
+	analyzer.embeddedTypeMap["Cache"] = []string{"store"}
+	analyzer.embeddedTypeMap["node"] = []string{"node"}
+
+	tests := map[string]string{
+		"Service.get":  "store.get",
+		"Service.Name": "Service.Name",
+		"Cache.Name":   "Cache.Name",
+		"Service.nope": "Service.nope",
+		"node.walk":    "node.walk",
+		"function":     "function",
+	}
+	for input, expected := range tests {
+		assert.Equal(t, expected, analyzer.promotedMethodName(input), "unexpected name for %s", input)
+	}
+}
+
+func TestResolvePromotedMethods(t *testing.T) {
+	analyzer := NewAnalyzer()
+	analyzer.declaredFuncInfo["Cache.Get"] = BlanketFunc{Name: "Cache.Get"}
+	analyzer.embeddedTypeMap["Service"] = []string{"Cache"}
+
+	input := set.New("Service.Get", "Service.Name", "function")
+	analyzer.resolvePromotedMethods(input)
+
+	assert.Equal(t, set.New("Cache.Get", "Service.Name", "function"), input, "expected promoted methods to be replaced by the methods they refer to")
+}
+
 func TestFindHelperFuncs(t *testing.T) {
 	analyzer := NewAnalyzer()
 
@@ -1120,13 +1968,13 @@ func TestAnalyze(t *testing.T) {
 					Line:     3,
 					Column:   1,
 				},
-				RBracePos: token.Position{
+				LBracePos: token.Position{
 					Filename: simpleMainPath,
 					Offset:   32,
 					Line:     3,
 					Column:   17,
 				},
-				LBracePos: token.Position{
+				RBracePos: token.Position{
 					Filename: simpleMainPath,
 					Offset:   46,
 					Line:     5,
@@ -1142,13 +1990,13 @@ func TestAnalyze(t *testing.T) {
 					Line:     7,
 					Column:   1,
 				},
-				RBracePos: token.Position{
+				LBracePos: token.Position{
 					Filename: simpleMainPath,
 					Offset:   65,
 					Line:     7,
 					Column:   17,
 				},
-				LBracePos: token.Position{
+				RBracePos: token.Position{
 					Filename: simpleMainPath,
 					Offset:   79,
 					Line:     9,
@@ -1164,13 +2012,13 @@ func TestAnalyze(t *testing.T) {
 					Line:     11,
 					Column:   1,
 				},
-				RBracePos: token.Position{
+				LBracePos: token.Position{
 					Filename: simpleMainPath,
 					Offset:   98,
 					Line:     11,
 					Column:   17,
 				},
-				LBracePos: token.Position{
+				RBracePos: token.Position{
 					Filename: simpleMainPath,
 					Offset:   112,
 					Line:     13,
@@ -1186,13 +2034,13 @@ func TestAnalyze(t *testing.T) {
 					Line:     15,
 					Column:   1,
 				},
-				RBracePos: token.Position{
+				LBracePos: token.Position{
 					Filename: simpleMainPath,
 					Offset:   130,
 					Line:     15,
 					Column:   16,
 				},
-				LBracePos: token.Position{
+				RBracePos: token.Position{
 					Filename: simpleMainPath,
 					Offset:   147,
 					Line:     19,
@@ -1200,8 +2048,25 @@ func TestAnalyze(t *testing.T) {
 				},
 			},
 		},
-		Called:   set.New("a", "c", "wrapper"),
-		Declared: set.New("a", "b", "c", "wrapper"),
+		Called:          set.New("a", "c", "wrapper"),
+		Declared:        set.New("a", "b", "c", "wrapper"),
+		CalledViaHelper: set.New(),
+		CalledByCategory: map[string]*set.Set{
+			"unit": set.New("a", "c", "wrapper"),
+		},
+		CalledBy: map[string]*set.Set{
+			"a":       set.New("TestA"),
+			"c":       set.New("TestC"),
+			"wrapper": set.New("TestWrapper"),
+		},
+		CallDepths: map[string]int{
+			"a":       0,
+			"b":       1,
+			"c":       0,
+			"wrapper": 0,
+		},
+		Suppressed: map[string]SuppressedFunc{},
+		Generated:  set.New(),
 	}
 	examplePath := util.BuildExamplePackagePath(t, "simple", false)
 	actual, err := analyzer.Analyze(examplePath)
@@ -1210,6 +2075,299 @@ func TestAnalyze(t *testing.T) {
 	assert.Equal(t, expected, actual, "expected output did not match actual output")
 }
 
+func TestAnalyzeListedPackage(t *testing.T) {
+	t.Run("normal", func(_t *testing.T) {
+		pkgs, err := listPackages(".", Options{}, "../example_packages/simple")
+		if err != nil {
+			t.Logf("failing because listPackages returned error: %v", err)
+			t.FailNow()
+		}
+
+		analyzer := NewAnalyzer()
+		actual, err := analyzer.analyzeListedPackage(pkgs[0])
+
+		assert.NoError(t, err)
+		if assert.NotNil(t, actual) {
+			assert.Equal(t, set.New("a", "b", "c", "wrapper"), actual.Declared)
+			assert.Equal(t, set.New("a", "c", "wrapper"), actual.Called)
+		}
+		assert.Equal(t, pkgs[0].ImportPath, analyzer.importPath)
+	})
+
+	t.Run("without any files", func(_t *testing.T) {
+		analyzer := NewAnalyzer()
+		_, err := analyzer.analyzeListedPackage(listedPackage{ImportPath: "example.com/empty", Name: "empty", Dir: t.TempDir()})
+		assert.Error(t, err)
+	})
+}
+
+func TestAnalyzeNestedSelectors(t *testing.T) {
+	analyzer := NewAnalyzer()
+
+	examplePath := util.BuildExamplePackagePath(t, "nested", false)
+	actual, err := analyzer.Analyze(examplePath)
+
+	assert.NoError(t, err, "Analyze produced an unexpected error")
+	assert.Equal(t, set.New("store.Save", "store.Load"), actual.Called)
+}
+
+func TestAnalyzeExternalTestPackage(t *testing.T) {
+	examplePath := util.BuildExamplePackagePath(t, "external", false)
+	expected := set.New("NewStore", "Store.Get", "Store.Put", "Greet", "normalize")
+
+	t.Run("heuristic", func(_t *testing.T) {
+		analyzer := NewAnalyzer()
+		actual, err := analyzer.Analyze(examplePath)
+
+		assert.NoError(t, err, "Analyze produced an unexpected error")
+		assert.Equal(t, expected, actual.Called)
+	})
+
+	t.Run("type checked", func(_t *testing.T) {
+		analyzer := NewAnalyzer()
+		analyzer.SetOptions(Options{TypeCheck: true})
+		actual, err := analyzer.Analyze(examplePath)
+
+		assert.NoError(t, err, "Analyze produced an unexpected error")
+		assert.Equal(t, expected, actual.Called)
+	})
+}
+
+func TestAnalyzeGenerics(t *testing.T) {
+	examplePath := util.BuildExamplePackagePath(t, "generics", false)
+	expectedDeclared := set.New("NewStack", "Stack.Push", "Stack.Pop", "Stack.Len", "Pair.Key", "Pair.Value", "Map")
+	expectedCalled := set.New("NewStack", "Stack.Push", "Stack.Pop", "Pair.Value", "Map")
+
+	t.Run("heuristic", func(_t *testing.T) {
+		analyzer := NewAnalyzer()
+		actual, err := analyzer.Analyze(examplePath)
+
+		assert.NoError(t, err, "Analyze produced an unexpected error")
+		assert.Equal(t, expectedDeclared, actual.Declared)
+		assert.Equal(t, expectedCalled, actual.Called)
+	})
+
+	t.Run("type checked", func(_t *testing.T) {
+		analyzer := NewAnalyzer()
+		analyzer.SetOptions(Options{TypeCheck: true})
+		actual, err := analyzer.Analyze(examplePath)
+
+		assert.NoError(t, err, "Analyze produced an unexpected error")
+		assert.Equal(t, expectedDeclared, actual.Declared)
+		assert.Equal(t, expectedCalled, actual.Called)
+	})
+}
+
+func TestAnalyzeFuncValues(t *testing.T) {
+	examplePath := util.BuildExamplePackagePath(t, "funcvalues", false)
+	expected := set.New("Parse", "A", "B", "handleIndex", "formatter.upper")
+
+	t.Run("heuristic", func(_t *testing.T) {
+		analyzer := NewAnalyzer()
+		actual, err := analyzer.Analyze(examplePath)
+
+		assert.NoError(t, err, "Analyze produced an unexpected error")
+		assert.Equal(t, expected, actual.Called)
+	})
+
+	t.Run("type checked", func(_t *testing.T) {
+		analyzer := NewAnalyzer()
+		analyzer.SetOptions(Options{TypeCheck: true})
+		actual, err := analyzer.Analyze(examplePath)
+
+		assert.NoError(t, err, "Analyze produced an unexpected error")
+		assert.Equal(t, expected, actual.Called)
+	})
+
+	t.Run("ignoring function values", func(_t *testing.T) {
+		analyzer := NewAnalyzer()
+		analyzer.SetOptions(Options{IgnoreFuncValues: true})
+		actual, err := analyzer.Analyze(examplePath)
+
+		assert.NoError(t, err, "Analyze produced an unexpected error")
+		assert.Equal(t, set.New(), actual.Called)
+	})
+
+	t.Run("type checked, ignoring function values", func(_t *testing.T) {
+		analyzer := NewAnalyzer()
+		analyzer.SetOptions(Options{TypeCheck: true, IgnoreFuncValues: true})
+		actual, err := analyzer.Analyze(examplePath)
+
+		assert.NoError(t, err, "Analyze produced an unexpected error")
+		assert.Equal(t, set.New(), actual.Called)
+	})
+}
+
+func TestAnalyzeInterfaceCalls(t *testing.T) {
+	examplePath := util.BuildExamplePackagePath(t, "interfaces", false)
+	expectedCalled := set.New("newMemStore", "diskStore.Set")
+	expectedViaInterface := set.New("memStore.Get", "memStore.Set", "diskStore.Get")
+
+	t.Run("disabled", func(_t *testing.T) {
+		analyzer := NewAnalyzer()
+		actual, err := analyzer.Analyze(examplePath)
+
+		assert.NoError(t, err, "Analyze produced an unexpected error")
+		assert.Equal(t, expectedCalled, actual.Called)
+		assert.Nil(t, actual.CalledViaInterface)
+	})
+
+	for name, typeCheck := range map[string]bool{"heuristic": false, "type checked": true} {
+		t.Run(name, func(_t *testing.T) {
+			analyzer := NewAnalyzer()
+			analyzer.SetOptions(Options{TypeCheck: typeCheck, InterfaceCalls: true})
+			actual, err := analyzer.Analyze(examplePath)
+
+			assert.NoError(t, err, "Analyze produced an unexpected error")
+			assert.Equal(t, expectedCalled, actual.Called)
+			assert.Equal(t, expectedViaInterface, actual.CalledViaInterface)
+
+			output := analyzer.GenerateDiffReport()
+			assert.Equal(t, 3, output.ViaInterfaceCount)
+			assert.Len(t, output.ViaInterface, 1)
+		})
+
+		t.Run(name+", counted", func(_t *testing.T) {
+			analyzer := NewAnalyzer()
+			analyzer.SetOptions(Options{TypeCheck: typeCheck, CountInterfaceCalls: true})
+			actual, err := analyzer.Analyze(examplePath)
+
+			assert.NoError(t, err, "Analyze produced an unexpected error")
+			assert.Equal(t, actual.Declared, actual.Called)
+			assert.Equal(t, expectedViaInterface, actual.CalledViaInterface)
+		})
+	}
+}
+
+func TestAnalyzeEmbedding(t *testing.T) {
+	examplePath := util.BuildExamplePackagePath(t, "embedding", false)
+	expected := set.New("NewService", "Cache.Lookup", "store.get", "Service.Name", "node.walk")
+
+	t.Run("heuristic", func(_t *testing.T) {
+		analyzer := NewAnalyzer()
+		actual, err := analyzer.Analyze(examplePath)
+
+		assert.NoError(t, err, "Analyze produced an unexpected error")
+		assert.Equal(t, expected, actual.Called)
+	})
+
+	t.Run("type checked", func(_t *testing.T) {
+		analyzer := NewAnalyzer()
+		analyzer.SetOptions(Options{TypeCheck: true})
+		actual, err := analyzer.Analyze(examplePath)
+
+		assert.NoError(t, err, "Analyze produced an unexpected error")
+		assert.Equal(t, expected, actual.Called)
+	})
+}
+
+func TestAnalyzeCategories(t *testing.T) {
+	examplePath := util.BuildExamplePackagePath(t, "categories", false)
+
+	for name, typeCheck := range map[string]bool{"heuristic": false, "type checked": true} {
+		t.Run(name, func(_t *testing.T) {
+			analyzer := NewAnalyzer()
+			analyzer.SetOptions(Options{TypeCheck: typeCheck, Tags: []string{"integration"}})
+			actual, err := analyzer.Analyze(examplePath)
+
+			assert.NoError(t, err, "Analyze produced an unexpected error")
+			assert.Equal(t, set.New("Parse", "Fetch"), actual.Called)
+			assert.Equal(t, map[string]*set.Set{
+				"unit":        set.New("Parse"),
+				"integration": set.New("Parse", "Fetch"),
+			}, actual.CalledByCategory)
+
+			output := analyzer.GenerateDiffReport()
+			assert.Equal(t, &categoryOutput{CalledCount: 1, Score: 33}, output.Categories["unit"])
+			assert.Equal(t, &categoryOutput{CalledCount: 2, Score: 66, Only: []string{"Fetch"}}, output.Categories["integration"])
+		})
+	}
+
+	t.Run("without tags", func(_t *testing.T) {
+		analyzer := NewAnalyzer()
+		actual, err := analyzer.Analyze(examplePath)
+
+		assert.NoError(t, err, "Analyze produced an unexpected error")
+		assert.Equal(t, map[string]*set.Set{"unit": set.New("Parse")}, actual.CalledByCategory)
+	})
+}
+
+func TestAnalyzeStatements(t *testing.T) {
+	examplePath := util.BuildExamplePackagePath(t, "statements", false)
+
+	t.Run("heuristic", func(_t *testing.T) {
+		analyzer := NewAnalyzer()
+		actual, err := analyzer.Analyze(examplePath)
+
+		assert.NoError(t, err, "Analyze produced an unexpected error")
+		assert.Empty(t, set.StringSlice(set.Difference(actual.Declared, actual.Called)), "every function in the statements package should be called")
+	})
+
+	t.Run("type checked", func(_t *testing.T) {
+		analyzer := NewAnalyzer()
+		analyzer.SetOptions(Options{TypeCheck: true})
+		actual, err := analyzer.Analyze(examplePath)
+
+		assert.NoError(t, err, "Analyze produced an unexpected error")
+		assert.Empty(t, set.StringSlice(set.Difference(actual.Declared, actual.Called)), "every function in the statements package should be called")
+	})
+}
+
+func TestAnalyzeGenerated(t *testing.T) {
+	examplePath := util.BuildExamplePackagePath(t, "generated", false)
+
+	t.Run("skipped by default", func(_t *testing.T) {
+		analyzer := NewAnalyzer()
+		actual, err := analyzer.Analyze(examplePath)
+
+		assert.NoError(t, err, "Analyze produced an unexpected error")
+		assert.Equal(t, set.New("Handwritten", "Untested"), actual.Declared)
+		assert.Equal(t, set.New("Color.String"), actual.Generated)
+		assert.Equal(t, 1, analyzer.GenerateDiffReport().GeneratedCount)
+	})
+
+	t.Run("included", func(_t *testing.T) {
+		analyzer := NewAnalyzer()
+		analyzer.SetOptions(Options{IncludeGenerated: true})
+		actual, err := analyzer.Analyze(examplePath)
+
+		assert.NoError(t, err, "Analyze produced an unexpected error")
+		assert.Equal(t, set.New("Handwritten", "Untested", "Color.String"), actual.Declared)
+		assert.True(t, actual.Generated.IsEmpty())
+	})
+}
+
+func TestCalledOnlyBy(t *testing.T) {
+	analyzer := NewAnalyzer()
+	analyzer.latestReport = &BlanketReport{
+		CalledByCategory: map[string]*set.Set{
+			"unit":        set.New("Parse"),
+			"integration": set.New("Parse", "Fetch"),
+		},
+	}
+
+	assert.True(t, analyzer.calledOnlyBy("integration", "Fetch"))
+	assert.False(t, analyzer.calledOnlyBy("integration", "Parse"), "expected functions called by other categories not to count")
+	assert.False(t, analyzer.calledOnlyBy("unit", "Fetch"), "expected functions called by other categories not to count")
+}
+
+func TestGroupByFilename(t *testing.T) {
+	analyzer := NewAnalyzer()
+	analyzer.declaredFuncInfo["later"] = BlanketFunc{Name: "later", Filename: "a.go", DeclPos: token.Position{Line: 9}}
+	analyzer.declaredFuncInfo["Earlier"] = BlanketFunc{Name: "Earlier", Filename: "a.go", DeclPos: token.Position{Line: 3}}
+	analyzer.declaredFuncInfo["B"] = BlanketFunc{Name: "B", Filename: "b.go", DeclPos: token.Position{Line: 1}}
+
+	longestFunctionNameLength := 0
+	actual := analyzer.groupByFilename([]string{"later", "B", "Earlier"}, &longestFunctionNameLength)
+
+	expected := map[string][]BlanketFunc{
+		"a.go": {analyzer.declaredFuncInfo["Earlier"], analyzer.declaredFuncInfo["later"]},
+		"b.go": {analyzer.declaredFuncInfo["B"]},
+	}
+	assert.Equal(t, expected, actual, "expected functions to be grouped by file and sorted by line")
+	assert.Equal(t, 7, longestFunctionNameLength)
+}
+
 func TestGenerateDiffReport(t *testing.T) {
 	analyzer := NewAnalyzer()
 	simpleMainPath := fmt.Sprintf("%s/main.go", util.BuildExamplePackagePath(t, "simple", true))
@@ -1224,13 +2382,13 @@ func TestGenerateDiffReport(t *testing.T) {
 					Line:     3,
 					Column:   1,
 				},
-				RBracePos: token.Position{
+				LBracePos: token.Position{
 					Filename: simpleMainPath,
 					Offset:   32,
 					Line:     3,
 					Column:   17,
 				},
-				LBracePos: token.Position{
+				RBracePos: token.Position{
 					Filename: simpleMainPath,
 					Offset:   46,
 					Line:     5,
@@ -1246,13 +2404,13 @@ func TestGenerateDiffReport(t *testing.T) {
 					Line:     7,
 					Column:   1,
 				},
-				RBracePos: token.Position{
+				LBracePos: token.Position{
 					Filename: simpleMainPath,
 					Offset:   65,
 					Line:     7,
 					Column:   17,
 				},
-				LBracePos: token.Position{
+				RBracePos: token.Position{
 					Filename: simpleMainPath,
 					Offset:   79,
 					Line:     9,
@@ -1268,13 +2426,13 @@ func TestGenerateDiffReport(t *testing.T) {
 					Line:     11,
 					Column:   1,
 				},
-				RBracePos: token.Position{
+				LBracePos: token.Position{
 					Filename: simpleMainPath,
 					Offset:   98,
 					Line:     11,
 					Column:   17,
 				},
-				LBracePos: token.Position{
+				RBracePos: token.Position{
 					Filename: simpleMainPath,
 					Offset:   112,
 					Line:     13,
@@ -1290,13 +2448,13 @@ func TestGenerateDiffReport(t *testing.T) {
 					Line:     15,
 					Column:   1,
 				},
-				RBracePos: token.Position{
+				LBracePos: token.Position{
 					Filename: simpleMainPath,
 					Offset:   130,
 					Line:     15,
 					Column:   16,
 				},
-				LBracePos: token.Position{
+				RBracePos: token.Position{
 					Filename: simpleMainPath,
 					Offset:   147,
 					Line:     19,
@@ -1313,6 +2471,12 @@ func TestGenerateDiffReport(t *testing.T) {
 		DeclaredCount:             4,
 		CalledCount:               3,
 		Score:                     75,
+		Functions: []functionOutput{
+			{Name: "A", Filename: simpleMainPath, Line: 3, Column: 1, EndLine: 5, Exported: true, Tested: true},
+			{Name: "B", Filename: simpleMainPath, Line: 7, Column: 1, EndLine: 9, Exported: true},
+			{Name: "C", Filename: simpleMainPath, Line: 11, Column: 1, EndLine: 13, Exported: true, Tested: true},
+			{Name: "wrapper", Filename: simpleMainPath, Line: 15, Column: 1, EndLine: 19, Tested: true},
+		},
 		Details: map[string][]BlanketFunc{
 			simpleMainPath: {
 				BlanketFunc{
@@ -1324,13 +2488,13 @@ func TestGenerateDiffReport(t *testing.T) {
 						Line:     7,
 						Column:   1,
 					},
-					RBracePos: token.Position{
+					LBracePos: token.Position{
 						Filename: simpleMainPath,
 						Offset:   65,
 						Line:     7,
 						Column:   17,
 					},
-					LBracePos: token.Position{
+					RBracePos: token.Position{
 						Filename: simpleMainPath,
 						Offset:   79,
 						Line:     9,
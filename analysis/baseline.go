@@ -0,0 +1,142 @@
+package analysis
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// BaselineFilename is the default name of the file a baseline is kept in, at the root of a project.
+	BaselineFilename = ".blanket-baseline.json"
+	// baselineVersion is the version of the baseline file format written by this version of blanket.
+	baselineVersion = 1
+)
+
+// Baseline records the functions that lacked direct unit tests when it was written, keyed by the import
+// path of the package they're declared in, so that only functions that lose their tests later fail a run.
+type Baseline struct {
+	Version  int                 `json:"version"`
+	Untested map[string][]string `json:"untested"`
+}
+
+// BaselineComparison is the difference between a run and a baseline. New holds the functions without direct
+// unit tests that aren't in the baseline, and Fixed the baseline entries that aren't untested anymore, because
+// they're tested now or no longer exist. Both are keyed by import path, and only cover the packages in the run.
+// A run narrowed down to changed functions or a scope only fixes the entries for the functions it analyzed.
+type BaselineComparison struct {
+	New   map[string][]string `json:"new,omitempty"`
+	Fixed map[string][]string `json:"fixed,omitempty"`
+}
+
+// untested returns the names of a package's functions without direct unit tests, sorted.
+func (o *blanketOutput) untested() []string {
+	var names []string
+	for _, funcs := range o.Details {
+		for _, f := range funcs {
+			names = append(names, f.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Baseline returns a baseline of every function without direct unit tests in the summary.
+func (s *blanketSummary) Baseline() *Baseline {
+	b := &Baseline{Version: baselineVersion, Untested: map[string][]string{}}
+	for _, pkg := range s.Packages {
+		if untested := pkg.untested(); len(untested) > 0 {
+			b.Untested[pkg.Package] = untested
+		}
+	}
+	return b
+}
+
+// CompareToBaseline compares the functions without direct unit tests in the summary to a given baseline.
+func (s *blanketSummary) CompareToBaseline(b *Baseline) *BaselineComparison {
+	// the functions a narrowed down run leaves out could still be untested, so they can't count as fixed
+	narrowed := s.Run != nil && (s.Run.Options.ChangesOnly || s.Run.Options.Scope != ScopeAll)
+
+	c := &BaselineComparison{New: map[string][]string{}, Fixed: map[string][]string{}}
+	for _, pkg := range s.Packages {
+		untested := map[string]bool{}
+		for _, name := range pkg.untested() {
+			untested[name] = true
+		}
+		analyzed := map[string]bool{}
+		for _, f := range pkg.Functions {
+			analyzed[f.Name] = true
+		}
+
+		known := map[string]bool{}
+		for _, name := range b.Untested[pkg.Package] {
+			known[name] = true
+			if !untested[name] && (analyzed[name] || !narrowed) {
+				c.Fixed[pkg.Package] = append(c.Fixed[pkg.Package], name)
+			}
+		}
+
+		for _, name := range pkg.untested() {
+			if !known[name] {
+				c.New[pkg.Package] = append(c.New[pkg.Package], name)
+			}
+		}
+	}
+	return c
+}
+
+// Regressed reports whether any function without direct unit tests is missing from the baseline.
+func (c *BaselineComparison) Regressed() bool {
+	return len(c.New) > 0
+}
+
+// Tighten returns a copy of the baseline without the entries a given comparison found to be fixed.
+func (b *Baseline) Tighten(c *BaselineComparison) *Baseline {
+	tightened := &Baseline{Version: baselineVersion, Untested: map[string][]string{}}
+	for importPath, names := range b.Untested {
+		fixed := map[string]bool{}
+		for _, name := range c.Fixed[importPath] {
+			fixed[name] = true
+		}
+
+		for _, name := range names {
+			if !fixed[name] {
+				tightened.Untested[importPath] = append(tightened.Untested[importPath], name)
+			}
+		}
+	}
+	return tightened
+}
+
+// LoadBaseline reads the baseline file at a given path.
+func LoadBaseline(path string) (*Baseline, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading baseline file")
+	}
+
+	b := &Baseline{}
+	if err := json.Unmarshal(data, b); err != nil {
+		return nil, errors.Wrap(err, "parsing baseline file")
+	}
+	if b.Version != baselineVersion {
+		return nil, errors.Errorf("unsupported baseline file version %d, expected %d", b.Version, baselineVersion)
+	}
+	return b, nil
+}
+
+// Write writes the baseline to a given path, with its entries sorted so that it diffs cleanly.
+func (b *Baseline) Write(path string) error {
+	for _, names := range b.Untested {
+		sort.Strings(names)
+	}
+
+	// json.Marshal sorts map keys, so the packages come out sorted too.
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "encoding baseline")
+	}
+	return errors.Wrap(ioutil.WriteFile(path, append(data, '\n'), 0644), "writing baseline file")
+}
@@ -0,0 +1,135 @@
+package analysis
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func buildBaselineSummary() *blanketSummary {
+	summary := &blanketSummary{}
+	summary.add(&blanketOutput{
+		Package: "example/api",
+		Details: map[string][]BlanketFunc{
+			"server.go": {{Name: "Server.Start"}, {Name: "handle"}},
+			"routes.go": {{Name: "routes"}},
+		},
+	})
+	summary.add(&blanketOutput{Package: "example/store"})
+	return summary
+}
+
+func TestBlanketOutputUntested(t *testing.T) {
+	t.Run("normal", func(_t *testing.T) {
+		output := &blanketOutput{
+			Details: map[string][]BlanketFunc{
+				"server.go": {{Name: "Server.Start"}, {Name: "handle"}},
+				"routes.go": {{Name: "routes"}, {Name: "Router.Add"}},
+			},
+		}
+
+		assert.Equal(t, []string{"Router.Add", "Server.Start", "handle", "routes"}, output.untested())
+	})
+
+	t.Run("without untested functions", func(_t *testing.T) {
+		output := &blanketOutput{}
+
+		assert.Empty(t, output.untested())
+	})
+}
+
+func TestBlanketSummaryBaseline(t *testing.T) {
+	expected := &Baseline{
+		Version:  baselineVersion,
+		Untested: map[string][]string{"example/api": {"Server.Start", "handle", "routes"}},
+	}
+
+	assert.Equal(t, expected, buildBaselineSummary().Baseline())
+}
+
+func TestBlanketSummaryCompareToBaseline(t *testing.T) {
+	baseline := &Baseline{
+		Version: baselineVersion,
+		Untested: map[string][]string{
+			"example/api":   {"Server.Start", "handle", "removed"},
+			"example/store": {"Store.Get"},
+			"example/other": {"notAnalyzed"},
+		},
+	}
+
+	actual := buildBaselineSummary().CompareToBaseline(baseline)
+
+	assert.True(t, actual.Regressed())
+	assert.Equal(t, map[string][]string{"example/api": {"routes"}}, actual.New)
+	assert.Equal(t, map[string][]string{"example/api": {"removed"}, "example/store": {"Store.Get"}}, actual.Fixed, "expected packages outside of the run to be left alone")
+
+	tightened := baseline.Tighten(actual)
+	expected := map[string][]string{
+		"example/api":   {"Server.Start", "handle"},
+		"example/other": {"notAnalyzed"},
+	}
+	assert.Equal(t, expected, tightened.Untested)
+	assert.False(t, buildBaselineSummary().CompareToBaseline(buildBaselineSummary().Baseline()).Regressed())
+
+	t.Run("narrowed down to changed functions", func(_t *testing.T) {
+		summary := &blanketSummary{Run: &runMetadata{Options: optionsOutput{ChangesOnly: true}}}
+		summary.add(&blanketOutput{
+			Package:   "example/api",
+			Functions: []functionOutput{{Name: "handle", Tested: true}, {Name: "routes"}},
+			Details:   map[string][]BlanketFunc{"routes.go": {{Name: "routes"}}},
+		})
+		baseline := &Baseline{
+			Version:  baselineVersion,
+			Untested: map[string][]string{"example/api": {"Server.Start", "handle", "routes"}},
+		}
+
+		actual := summary.CompareToBaseline(baseline)
+
+		assert.Equal(t, map[string][]string{"example/api": {"handle"}}, actual.Fixed, "expected functions the run left out to be left alone")
+		assert.Equal(t, map[string][]string{"example/api": {"Server.Start", "routes"}}, baseline.Tighten(actual).Untested)
+	})
+
+	t.Run("narrowed down to a scope", func(_t *testing.T) {
+		summary := &blanketSummary{Run: &runMetadata{Options: optionsOutput{Scope: ScopeExported}}}
+		summary.add(&blanketOutput{Package: "example/api", Functions: []functionOutput{{Name: "Server.Start", Tested: true}}})
+		baseline := &Baseline{
+			Version:  baselineVersion,
+			Untested: map[string][]string{"example/api": {"Server.Start", "handle"}},
+		}
+
+		actual := summary.CompareToBaseline(baseline)
+
+		assert.Equal(t, map[string][]string{"example/api": {"Server.Start"}}, actual.Fixed)
+	})
+}
+
+func TestLoadBaseline(t *testing.T) {
+	t.Run("written by Write", func(_t *testing.T) {
+		path := filepath.Join(t.TempDir(), BaselineFilename)
+		expected := buildBaselineSummary().Baseline()
+
+		assert.NoError(t, expected.Write(path))
+		actual, err := LoadBaseline(path)
+
+		assert.NoError(t, err)
+		assert.Equal(t, expected, actual)
+	})
+
+	t.Run("nonexistent file", func(_t *testing.T) {
+		_, err := LoadBaseline(filepath.Join(t.TempDir(), BaselineFilename))
+		assert.Error(t, err)
+	})
+
+	t.Run("unsupported version", func(_t *testing.T) {
+		path := filepath.Join(t.TempDir(), BaselineFilename)
+		if err := ioutil.WriteFile(path, []byte(`{"version": 2, "untested": {}}`), 0644); err != nil {
+			t.Logf("failing because WriteFile returned error: %v", err)
+			t.FailNow()
+		}
+
+		_, err := LoadBaseline(path)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,164 @@
+package analysis
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"gitlab.com/verygoodsoftwarenotvirus/blanket/lib/git"
+
+	"github.com/pkg/errors"
+)
+
+// Comparison is the difference between the results for two revisions of a repository. The functions are keyed
+// by the directory of the package they're declared in, relative to the root of the repository.
+type Comparison struct {
+	Base      string `json:"base"`
+	Head      string `json:"head"`
+	BaseScore int    `json:"base_score"`
+	HeadScore int    `json:"head_score"`
+
+	// NewlyUntested holds the functions that had a direct unit test in the base revision, but don't in the head
+	// revision, and NewlyTested the other way around.
+	NewlyUntested map[string][]string `json:"newly_untested,omitempty"`
+	NewlyTested   map[string][]string `json:"newly_tested,omitempty"`
+
+	// AddedUntested holds the functions added in the head revision without a direct unit test, and Removed
+	// the functions in the base revision that the head revision doesn't have anymore.
+	AddedUntested map[string][]string `json:"added_untested,omitempty"`
+	Removed       map[string][]string `json:"removed,omitempty"`
+}
+
+// ScoreChange returns the difference between the grades of the head and base revisions.
+func (c *Comparison) ScoreChange() int {
+	return c.HeadScore - c.BaseScore
+}
+
+// CompareRevisions checks two revisions of the repository a given directory is in out into temporary worktrees,
+// analyzes the packages matched by the provided patterns in each (resolving them from the same directory of
+// the worktree), and compares the results. Options.Root is moved into each worktree the same way.
+func CompareRevisions(dir, base, head string, opts Options, patterns ...string) (*Comparison, error) {
+	root, err := git.Root(dir)
+	if err != nil {
+		return nil, err
+	}
+	rel, err := relativeTo(root, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	tmp, err := ioutil.TempDir("", "blanket-compare")
+	if err != nil {
+		return nil, errors.Wrap(err, "creating temporary directory")
+	}
+	defer os.RemoveAll(tmp)
+	if resolved, err := filepath.EvalSymlinks(tmp); err == nil {
+		tmp = resolved
+	}
+
+	var summaries []*blanketSummary
+	var worktrees []string
+	for i, rev := range []string{base, head} {
+		worktree := filepath.Join(tmp, []string{"base", "head"}[i])
+		if err := git.AddWorktree(root, rev, worktree); err != nil {
+			return nil, errors.Wrapf(err, "checking out %s", rev)
+		}
+		defer git.RemoveWorktree(root, worktree)
+
+		revOpts := opts
+		if opts.Root != "" {
+			r, err := relativeTo(root, opts.Root)
+			if err != nil {
+				return nil, err
+			}
+			revOpts.Root = filepath.Join(worktree, r)
+		}
+
+		summary, err := analyzePackagesIn(filepath.Join(worktree, rel), revOpts, patterns...)
+		if err != nil {
+			return nil, errors.Wrapf(err, "analyzing %s", rev)
+		}
+		summaries = append(summaries, summary)
+		worktrees = append(worktrees, worktree)
+	}
+
+	c := compareSummaries(summaries[0], summaries[1], worktrees[0], worktrees[1])
+	c.Base, c.Head = base, head
+	return c, nil
+}
+
+// relativeTo returns the path of a given directory relative to a given root, with symlinks resolved.
+func relativeTo(root, dir string) (string, error) {
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+		dir = resolved
+	}
+	rel, err := filepath.Rel(root, dir)
+	if err != nil {
+		return "", errors.Wrapf(err, "finding %s in %s", dir, root)
+	}
+	return rel, nil
+}
+
+// functionStatuses returns whether each function in a summary has a direct unit test, keyed by the directory of
+// its package relative to a given root, then by name.
+func functionStatuses(s *blanketSummary, root string) map[string]map[string]bool {
+	statuses := map[string]map[string]bool{}
+	for _, pkg := range s.Packages {
+		dir, err := filepath.Rel(root, pkg.Dir)
+		if err != nil {
+			dir = pkg.Dir
+		}
+		dir = filepath.ToSlash(dir)
+
+		if statuses[dir] == nil {
+			statuses[dir] = map[string]bool{}
+		}
+		for _, f := range pkg.Functions {
+			statuses[dir][f.Name] = f.Tested
+		}
+	}
+	return statuses
+}
+
+// compareSummaries compares the summaries for two revisions, checked out at the given roots.
+func compareSummaries(base, head *blanketSummary, baseRoot, headRoot string) *Comparison {
+	c := &Comparison{
+		BaseScore:     base.Score,
+		HeadScore:     head.Score,
+		NewlyUntested: map[string][]string{},
+		NewlyTested:   map[string][]string{},
+		AddedUntested: map[string][]string{},
+		Removed:       map[string][]string{},
+	}
+
+	baseStatuses := functionStatuses(base, baseRoot)
+	headStatuses := functionStatuses(head, headRoot)
+	for dir, funcs := range headStatuses {
+		for name, tested := range funcs {
+			wasTested, existed := baseStatuses[dir][name]
+			switch {
+			case !existed && !tested:
+				c.AddedUntested[dir] = append(c.AddedUntested[dir], name)
+			case existed && wasTested && !tested:
+				c.NewlyUntested[dir] = append(c.NewlyUntested[dir], name)
+			case existed && !wasTested && tested:
+				c.NewlyTested[dir] = append(c.NewlyTested[dir], name)
+			}
+		}
+	}
+	for dir, funcs := range baseStatuses {
+		for name := range funcs {
+			if _, ok := headStatuses[dir][name]; !ok {
+				c.Removed[dir] = append(c.Removed[dir], name)
+			}
+		}
+	}
+
+	for _, group := range []map[string][]string{c.NewlyUntested, c.NewlyTested, c.AddedUntested, c.Removed} {
+		for _, names := range group {
+			sort.Strings(names)
+		}
+	}
+	return c
+}
@@ -0,0 +1,170 @@
+package analysis
+
+import (
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"gitlab.com/verygoodsoftwarenotvirus/blanket/lib/util"
+
+	"github.com/stretchr/testify/assert"
+)
+
+////////////////////////////////////////////////////////
+//                                                    //
+//               Test Helper Functions                //
+//                                                    //
+////////////////////////////////////////////////////////
+
+func commitFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	util.WriteFiles(t, dir, files)
+
+	for _, args := range [][]string{{"add", "."}, {"commit", "-qm", "change"}} {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Logf("failing because git %v returned error: %v\n%s", args, err, out)
+			t.FailNow()
+		}
+	}
+}
+
+////////////////////////////////////////////////////////
+//                                                    //
+//                   Actual Tests                     //
+//                                                    //
+////////////////////////////////////////////////////////
+
+func TestRelativeTo(t *testing.T) {
+	root := t.TempDir()
+	resolvedRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		t.Logf("failing because EvalSymlinks returned error: %v", err)
+		t.FailNow()
+	}
+	util.WriteFiles(t, root, map[string]string{"api/server.go": "package api\n"})
+
+	t.Run("normal", func(_t *testing.T) {
+		actual, err := relativeTo(resolvedRoot, filepath.Join(root, "api"))
+
+		assert.NoError(t, err)
+		assert.Equal(t, "api", actual)
+	})
+
+	t.Run("the root itself", func(_t *testing.T) {
+		actual, err := relativeTo(resolvedRoot, root)
+
+		assert.NoError(t, err)
+		assert.Equal(t, ".", actual)
+	})
+
+	t.Run("with a relative root", func(_t *testing.T) {
+		_, err := relativeTo("relative", resolvedRoot)
+		assert.Error(t, err)
+	})
+}
+
+func TestFunctionStatuses(t *testing.T) {
+	summary := &blanketSummary{}
+	summary.add(&blanketOutput{
+		Dir:       filepath.FromSlash("/src/project/api/v2"),
+		Functions: []functionOutput{{Name: "Start", Tested: true}, {Name: "handle"}},
+	})
+	summary.add(&blanketOutput{
+		Dir:       filepath.FromSlash("/src/project"),
+		Functions: []functionOutput{{Name: "main"}},
+	})
+
+	expected := map[string]map[string]bool{
+		"api/v2": {"Start": true, "handle": false},
+		".":      {"main": false},
+	}
+	assert.Equal(t, expected, functionStatuses(summary, filepath.FromSlash("/src/project")))
+}
+
+func TestCompareSummaries(t *testing.T) {
+	base := &blanketSummary{Score: 50}
+	base.add(&blanketOutput{
+		Dir: filepath.FromSlash("/base/api"),
+		Functions: []functionOutput{
+			{Name: "Lost", Tested: true},
+			{Name: "Gained"},
+			{Name: "Removed"},
+			{Name: "Same", Tested: true},
+		},
+	})
+	head := &blanketSummary{Score: 60}
+	head.add(&blanketOutput{
+		Dir: filepath.FromSlash("/head/api"),
+		Functions: []functionOutput{
+			{Name: "Lost"},
+			{Name: "Gained", Tested: true},
+			{Name: "Same", Tested: true},
+			{Name: "Added"},
+			{Name: "AddedTested", Tested: true},
+		},
+	})
+	head.add(&blanketOutput{
+		Dir:       filepath.FromSlash("/head"),
+		Functions: []functionOutput{{Name: "main"}},
+	})
+
+	expected := &Comparison{
+		BaseScore:     50,
+		HeadScore:     60,
+		NewlyUntested: map[string][]string{"api": {"Lost"}},
+		NewlyTested:   map[string][]string{"api": {"Gained"}},
+		AddedUntested: map[string][]string{"api": {"Added"}, ".": {"main"}},
+		Removed:       map[string][]string{"api": {"Removed"}},
+	}
+	actual := compareSummaries(base, head, filepath.FromSlash("/base"), filepath.FromSlash("/head"))
+
+	assert.Equal(t, expected, actual)
+	assert.Equal(t, 10, actual.ScoreChange())
+}
+
+func TestCompareRevisions(t *testing.T) {
+	dir := buildExampleModule(t)
+	cmd := exec.Command("git", "init", "-q")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Logf("failing because git init returned error: %v\n%s", err, out)
+		t.FailNow()
+	}
+	commitFiles(t, dir, nil)
+	commitFiles(t, dir, map[string]string{
+		"thing.go":      "package thing\n\nfunc A() {}\n\nfunc B() {}\n\nfunc D() {}\n",
+		"thing_test.go": "package thing\n\nimport \"testing\"\n\nfunc TestB(t *testing.T) {\n\tB()\n}\n",
+	})
+
+	t.Run("normal", func(_t *testing.T) {
+		actual, err := CompareRevisions(dir, "HEAD~1", "HEAD", Options{}, "./...")
+
+		assert.NoError(t, err)
+		if assert.NotNil(t, actual) {
+			assert.Equal(t, "HEAD~1", actual.Base)
+			assert.Equal(t, map[string][]string{".": {"A"}}, actual.NewlyUntested)
+			assert.Equal(t, map[string][]string{".": {"B"}}, actual.NewlyTested)
+			assert.Equal(t, map[string][]string{".": {"D"}}, actual.AddedUntested)
+			assert.Empty(t, actual.Removed)
+			assert.Equal(t, 66, actual.BaseScore)
+			assert.Equal(t, 50, actual.HeadScore)
+		}
+	})
+
+	t.Run("from a subdirectory", func(_t *testing.T) {
+		actual, err := CompareRevisions(filepath.Join(dir, "sub"), "HEAD~1", "HEAD", Options{}, ".")
+
+		assert.NoError(t, err)
+		if assert.NotNil(t, actual) {
+			assert.Equal(t, 0, actual.ScoreChange())
+			assert.Empty(t, actual.NewlyUntested)
+		}
+	})
+
+	t.Run("nonexistent revision", func(_t *testing.T) {
+		_, err := CompareRevisions(dir, "nonexistent", "HEAD", Options{}, "./...")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,190 @@
+package analysis
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// sortFunctionOutputs sorts functions by position.
+func sortFunctionOutputs(functions []functionOutput) {
+	sort.Slice(functions, func(i, j int) bool {
+		if functions[i].Filename != functions[j].Filename {
+			return functions[i].Filename < functions[j].Filename
+		}
+		return functions[i].Line < functions[j].Line
+	})
+}
+
+// LoadReport reads a report saved from the JSON output of analyze, which is either the report for a single
+// package or the summary of several. Reports without per-function detail (from older versions), and ones
+// written with a newer schema version than this one, are an error.
+func LoadReport(path string) (*blanketSummary, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading report")
+	}
+
+	summary := &blanketSummary{}
+	if err := json.Unmarshal(data, summary); err != nil {
+		return nil, errors.Wrapf(err, "parsing report %s", path)
+	}
+	if summary.Packages == nil {
+		pkg := &blanketOutput{}
+		if err := json.Unmarshal(data, pkg); err != nil {
+			return nil, errors.Wrapf(err, "parsing report %s", path)
+		}
+		summary = &blanketSummary{SchemaVersion: pkg.SchemaVersion, Run: pkg.Run}
+		summary.add(pkg)
+		summary.Score = pkg.Score
+	}
+
+	if summary.SchemaVersion > SchemaVersion {
+		return nil, fmt.Errorf("report %s has schema version %d, but this version of blanket only reads up to %d", path, summary.SchemaVersion, SchemaVersion)
+	}
+
+	for _, pkg := range summary.Packages {
+		if pkg.DeclaredCount > 0 && len(pkg.Functions) == 0 {
+			return nil, fmt.Errorf("report %s doesn't list the functions in %s, it was likely written by an older version of blanket", path, pkg.Package)
+		}
+	}
+	return summary, nil
+}
+
+// ReportDiff is the difference between two reports.
+type ReportDiff struct {
+	OldScore int           `json:"old_score"`
+	NewScore int           `json:"new_score"`
+	Packages []packageDiff `json:"packages"`
+}
+
+// packageDiff is the difference between the reports for a single package. AddedUntested holds the functions
+// without a direct unit test in the new report that weren't in the old one, either because they're new or
+// because they lost their test, and RemovedUntested the other way around.
+type packageDiff struct {
+	Package         string   `json:"package"`
+	Added           bool     `json:"added,omitempty"`
+	Removed         bool     `json:"removed,omitempty"`
+	OldScore        int      `json:"old_score"`
+	NewScore        int      `json:"new_score"`
+	AddedUntested   []string `json:"added_untested,omitempty"`
+	RemovedUntested []string `json:"removed_untested,omitempty"`
+}
+
+// ScoreChange returns the difference between the total grades of the new and old reports.
+func (d *ReportDiff) ScoreChange() int {
+	return d.NewScore - d.OldScore
+}
+
+// Changed reports whether anything about a package is different between the reports.
+func (p packageDiff) Changed() bool {
+	return p.Added || p.Removed || p.OldScore != p.NewScore || len(p.AddedUntested) > 0 || len(p.RemovedUntested) > 0
+}
+
+// untestedFunctions returns the names of a package's functions without a direct unit test.
+func untestedFunctions(pkg *blanketOutput) map[string]bool {
+	untested := map[string]bool{}
+	if pkg == nil {
+		return untested
+	}
+	for _, f := range pkg.Functions {
+		if !f.Tested {
+			untested[f.Name] = true
+		}
+	}
+	return untested
+}
+
+// DiffReports compares two reports package by package, and function by function. Packages are sorted by import path.
+func DiffReports(oldReport, newReport *blanketSummary) *ReportDiff {
+	d := &ReportDiff{OldScore: oldReport.Score, NewScore: newReport.Score}
+
+	packages := map[string][2]*blanketOutput{}
+	for _, pkg := range oldReport.Packages {
+		packages[pkg.Package] = [2]*blanketOutput{pkg, packages[pkg.Package][1]}
+	}
+	for _, pkg := range newReport.Packages {
+		packages[pkg.Package] = [2]*blanketOutput{packages[pkg.Package][0], pkg}
+	}
+
+	for importPath, pair := range packages {
+		p := packageDiff{Package: importPath, Added: pair[0] == nil, Removed: pair[1] == nil}
+		if pair[0] != nil {
+			p.OldScore = pair[0].Score
+		}
+		if pair[1] != nil {
+			p.NewScore = pair[1].Score
+		}
+
+		oldUntested, newUntested := untestedFunctions(pair[0]), untestedFunctions(pair[1])
+		for name := range newUntested {
+			if !oldUntested[name] {
+				p.AddedUntested = append(p.AddedUntested, name)
+			}
+		}
+		for name := range oldUntested {
+			if !newUntested[name] {
+				p.RemovedUntested = append(p.RemovedUntested, name)
+			}
+		}
+		sort.Strings(p.AddedUntested)
+		sort.Strings(p.RemovedUntested)
+		d.Packages = append(d.Packages, p)
+	}
+
+	sort.Slice(d.Packages, func(i, j int) bool {
+		return d.Packages[i].Package < d.Packages[j].Package
+	})
+	return d
+}
+
+// RegressionRule decides which differences between two reports count as a regression.
+type RegressionRule struct {
+	// NewUntested fails on any function without a direct unit test that wasn't in the old report.
+	NewUntested bool
+	// ScoreDrop fails when the total grade drops, and PackageScoreDrop when the grade of any package that's in
+	// both reports does. Either allows the grade to drop by up to Tolerance percentage points.
+	ScoreDrop        bool
+	PackageScoreDrop bool
+	Tolerance        int
+}
+
+// ParseRegressionRule builds a rule from the names accepted by --fail-on: untested, score, package-score and none.
+func ParseRegressionRule(names []string, tolerance int) (RegressionRule, error) {
+	rule := RegressionRule{Tolerance: tolerance}
+	for _, name := range names {
+		switch name {
+		case "untested":
+			rule.NewUntested = true
+		case "score":
+			rule.ScoreDrop = true
+		case "package-score":
+			rule.PackageScoreDrop = true
+		case "none":
+		default:
+			return rule, fmt.Errorf("invalid regression rule %q, expected untested, score, package-score or none", name)
+		}
+	}
+	return rule, nil
+}
+
+// Regressions describes every regression a given rule finds in the diff.
+func (d *ReportDiff) Regressions(rule RegressionRule) []string {
+	var regressions []string
+	if rule.ScoreDrop && d.OldScore-d.NewScore > rule.Tolerance {
+		regressions = append(regressions, fmt.Sprintf("total grade dropped from %d%% to %d%%", d.OldScore, d.NewScore))
+	}
+
+	for _, p := range d.Packages {
+		if rule.PackageScoreDrop && !p.Added && !p.Removed && p.OldScore-p.NewScore > rule.Tolerance {
+			regressions = append(regressions, fmt.Sprintf("grade for %s dropped from %d%% to %d%%", p.Package, p.OldScore, p.NewScore))
+		}
+		if rule.NewUntested && len(p.AddedUntested) > 0 {
+			regressions = append(regressions, fmt.Sprintf("%s has %d new functions without direct unit tests", p.Package, len(p.AddedUntested)))
+		}
+	}
+	return regressions
+}
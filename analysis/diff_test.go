@@ -0,0 +1,210 @@
+package analysis
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+////////////////////////////////////////////////////////
+//                                                    //
+//               Test Helper Functions                //
+//                                                    //
+////////////////////////////////////////////////////////
+
+func writeReport(t *testing.T, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "report.json")
+	if err := ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Logf("failing because WriteFile returned error: %v", err)
+		t.FailNow()
+	}
+	return path
+}
+
+func buildReportSummary(score int, packages ...*blanketOutput) *blanketSummary {
+	summary := &blanketSummary{}
+	for _, pkg := range packages {
+		summary.add(pkg)
+	}
+	summary.Score = score
+	return summary
+}
+
+////////////////////////////////////////////////////////
+//                                                    //
+//                   Actual Tests                     //
+//                                                    //
+////////////////////////////////////////////////////////
+
+func TestLoadReport(t *testing.T) {
+	t.Run("single package", func(_t *testing.T) {
+		path := writeReport(t, `{"package": "example/api", "declared": 2, "called": 1, "score": 50, "functions": [
+			{"name": "A", "filename": "/src/api/a.go", "line": 3, "tested": true},
+			{"name": "B", "filename": "/src/api/a.go", "line": 5, "tested": false}
+		]}`)
+
+		actual, err := LoadReport(path)
+
+		assert.NoError(t, err)
+		if assert.NotNil(t, actual) && assert.Len(t, actual.Packages, 1) {
+			assert.Equal(t, 50, actual.Score)
+			assert.Equal(t, "example/api", actual.Packages[0].Package)
+			assert.Len(t, actual.Packages[0].Functions, 2)
+		}
+	})
+
+	t.Run("several packages", func(_t *testing.T) {
+		path := writeReport(t, `{"packages": [
+			{"package": "example/api", "declared": 1, "called": 1, "score": 100, "functions": [{"name": "A", "tested": true}]},
+			{"package": "example/store", "declared": 0, "called": 0, "score": 100, "functions": []}
+		], "declared": 1, "called": 1, "score": 100}`)
+
+		actual, err := LoadReport(path)
+
+		assert.NoError(t, err)
+		if assert.NotNil(t, actual) {
+			assert.Len(t, actual.Packages, 2)
+			assert.Equal(t, 100, actual.Score)
+		}
+	})
+
+	t.Run("without per-function detail", func(_t *testing.T) {
+		_, err := LoadReport(writeReport(t, `{"package": "example/api", "declared": 2, "called": 1, "score": 50}`))
+		assert.Error(t, err)
+	})
+
+	t.Run("newer schema version", func(_t *testing.T) {
+		_, err := LoadReport(writeReport(t, `{"schema_version": 99, "package": "example/api", "declared": 0, "called": 0, "score": 100, "functions": []}`))
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid JSON", func(_t *testing.T) {
+		_, err := LoadReport(writeReport(t, `{"package": `))
+		assert.Error(t, err)
+	})
+
+	t.Run("nonexistent file", func(_t *testing.T) {
+		_, err := LoadReport(filepath.Join(t.TempDir(), "report.json"))
+		assert.Error(t, err)
+	})
+}
+
+func TestDiffReports(t *testing.T) {
+	oldReport := buildReportSummary(60,
+		&blanketOutput{Package: "example/api", Score: 50, Functions: []functionOutput{
+			{Name: "Fixed"}, {Name: "Broken", Tested: true}, {Name: "Deleted"}, {Name: "Same"},
+		}},
+		&blanketOutput{Package: "example/old", Score: 100, Functions: []functionOutput{{Name: "A", Tested: true}}},
+		&blanketOutput{Package: "example/store", Score: 100, Functions: []functionOutput{{Name: "Get", Tested: true}}},
+	)
+	newReport := buildReportSummary(55,
+		&blanketOutput{Package: "example/api", Score: 40, Functions: []functionOutput{
+			{Name: "Fixed", Tested: true}, {Name: "Broken"}, {Name: "Same"}, {Name: "Added"},
+		}},
+		&blanketOutput{Package: "example/new", Score: 0, Functions: []functionOutput{{Name: "B"}}},
+		&blanketOutput{Package: "example/store", Score: 100, Functions: []functionOutput{{Name: "Get", Tested: true}}},
+	)
+
+	expected := &ReportDiff{
+		OldScore: 60,
+		NewScore: 55,
+		Packages: []packageDiff{
+			{Package: "example/api", OldScore: 50, NewScore: 40, AddedUntested: []string{"Added", "Broken"}, RemovedUntested: []string{"Deleted", "Fixed"}},
+			{Package: "example/new", Added: true, AddedUntested: []string{"B"}},
+			{Package: "example/old", Removed: true, OldScore: 100},
+			{Package: "example/store", OldScore: 100, NewScore: 100},
+		},
+	}
+	actual := DiffReports(oldReport, newReport)
+
+	assert.Equal(t, expected, actual)
+	assert.Equal(t, -5, actual.ScoreChange())
+	assert.False(t, actual.Packages[3].Changed())
+	assert.True(t, actual.Packages[2].Changed())
+}
+
+func TestSortFunctionOutputs(t *testing.T) {
+	functions := []functionOutput{
+		{Name: "C", Filename: "b.go", Line: 3},
+		{Name: "B", Filename: "a.go", Line: 12},
+		{Name: "A", Filename: "a.go", Line: 4},
+	}
+
+	sortFunctionOutputs(functions)
+
+	expected := []functionOutput{
+		{Name: "A", Filename: "a.go", Line: 4},
+		{Name: "B", Filename: "a.go", Line: 12},
+		{Name: "C", Filename: "b.go", Line: 3},
+	}
+	assert.Equal(t, expected, functions, "expected functions to be sorted by file, then line")
+}
+
+func TestPackageDiffChanged(t *testing.T) {
+	unchanged := packageDiff{Package: "example/store", OldScore: 100, NewScore: 100}
+	assert.False(t, unchanged.Changed())
+
+	for name, diff := range map[string]packageDiff{
+		"added":            {Added: true},
+		"removed":          {Removed: true, OldScore: 100},
+		"score":            {OldScore: 50, NewScore: 40},
+		"added untested":   {AddedUntested: []string{"B"}},
+		"removed untested": {RemovedUntested: []string{"A"}},
+	} {
+		assert.True(t, diff.Changed(), "expected %s package to have changed", name)
+	}
+}
+
+func TestUntestedFunctions(t *testing.T) {
+	t.Run("normal", func(_t *testing.T) {
+		pkg := &blanketOutput{Functions: []functionOutput{{Name: "A", Tested: true}, {Name: "B"}, {Name: "C"}}}
+
+		assert.Equal(t, map[string]bool{"B": true, "C": true}, untestedFunctions(pkg))
+	})
+
+	t.Run("missing package", func(_t *testing.T) {
+		assert.Empty(t, untestedFunctions(nil))
+	})
+}
+
+func TestParseRegressionRule(t *testing.T) {
+	t.Run("normal", func(_t *testing.T) {
+		actual, err := ParseRegressionRule([]string{"untested", "package-score"}, 2)
+
+		assert.NoError(t, err)
+		assert.Equal(t, RegressionRule{NewUntested: true, PackageScoreDrop: true, Tolerance: 2}, actual)
+	})
+
+	t.Run("none", func(_t *testing.T) {
+		actual, err := ParseRegressionRule([]string{"none"}, 0)
+
+		assert.NoError(t, err)
+		assert.Equal(t, RegressionRule{}, actual)
+	})
+
+	t.Run("invalid", func(_t *testing.T) {
+		_, err := ParseRegressionRule([]string{"coverage"}, 0)
+		assert.Error(t, err)
+	})
+}
+
+func TestReportDiffRegressions(t *testing.T) {
+	diff := &ReportDiff{
+		OldScore: 60,
+		NewScore: 55,
+		Packages: []packageDiff{
+			{Package: "example/api", OldScore: 50, NewScore: 40, AddedUntested: []string{"Added"}},
+			{Package: "example/new", Added: true, NewScore: 0},
+			{Package: "example/store", OldScore: 100, NewScore: 99},
+		},
+	}
+
+	assert.Empty(t, diff.Regressions(RegressionRule{}))
+	assert.Equal(t, []string{"example/api has 1 new functions without direct unit tests"}, diff.Regressions(RegressionRule{NewUntested: true}))
+	assert.Equal(t, []string{"total grade dropped from 60% to 55%"}, diff.Regressions(RegressionRule{ScoreDrop: true}))
+	assert.Empty(t, diff.Regressions(RegressionRule{ScoreDrop: true, Tolerance: 5}))
+	assert.Equal(t, []string{"grade for example/api dropped from 50% to 40%"}, diff.Regressions(RegressionRule{PackageScoreDrop: true, Tolerance: 1}))
+}
@@ -0,0 +1,138 @@
+package analysis
+
+import (
+	"fmt"
+	"go/token"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Scope narrows the analyzed functions down by visibility.
+type Scope string
+
+const (
+	// ScopeAll, the zero value, analyzes every function.
+	ScopeAll Scope = ""
+	// ScopeExported only analyzes exported functions, and the exported methods of exported types.
+	ScopeExported Scope = "exported"
+	// ScopeUnexported only analyzes the functions ScopeExported leaves out.
+	ScopeUnexported Scope = "unexported"
+)
+
+// ParseScope parses the name of a scope, as passed to --scope.
+func ParseScope(in string) (Scope, error) {
+	switch in {
+	case "", "all":
+		return ScopeAll, nil
+	case string(ScopeExported), string(ScopeUnexported):
+		return Scope(in), nil
+	}
+	return ScopeAll, fmt.Errorf("invalid scope %q, expected exported, unexported or all", in)
+}
+
+// includes reports whether a function with a given name (i.e. `Type.Method`) is in the scope. A method only
+// counts as exported if both its name and the name of its receiver's type are.
+func (s Scope) includes(name string) bool {
+	if s == ScopeAll {
+		return true
+	}
+
+	exported := true
+	for _, part := range strings.Split(name, ".") {
+		exported = exported && token.IsExported(part)
+	}
+	return exported == (s == ScopeExported)
+}
+
+// matchPath reports whether a slash separated path relative to the project root matches a given pattern. Patterns
+// use path.Match syntax, and match either the path itself or the directory it's in. A pattern without a slash also
+// matches the name of the file in any directory (i.e. `*_gen.go`). A pattern ending in `/...` matches everything
+// under that directory instead, the way package patterns do (i.e. `internal/mocks/...`).
+func matchPath(pattern, rel string) bool {
+	if strings.HasSuffix(pattern, "/...") {
+		prefix := strings.TrimSuffix(pattern, "/...")
+		return prefix == "." || rel == prefix || strings.HasPrefix(rel, prefix+"/")
+	}
+
+	if ok, _ := path.Match(pattern, rel); ok {
+		return true
+	}
+	if !strings.Contains(pattern, "/") {
+		if ok, _ := path.Match(pattern, path.Base(rel)); ok {
+			return true
+		}
+	}
+	ok, _ := path.Match(pattern, path.Dir(rel))
+	return ok
+}
+
+// relativePath returns the path of a given file relative to the root configured in the options, using forward
+// slashes. It returns false if there's no root, or the file isn't inside of it.
+func (a *analyzer) relativePath(filename string) (string, bool) {
+	if a.options.Root == "" {
+		return "", false
+	}
+
+	rel, err := filepath.Rel(a.options.Root, filename)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
+
+// included reports whether a function with a given name, declared in a given file, should be analyzed at all
+// according to the scope and the include and exclude patterns in the options.
+func (a *analyzer) included(name, filename string) bool {
+	if !a.options.Scope.includes(name) {
+		return false
+	}
+
+	if rel, ok := a.relativePath(filename); ok {
+		if len(a.options.IncludePaths) > 0 && !matchesAnyPath(a.options.IncludePaths, rel) {
+			return false
+		}
+		if matchesAnyPath(a.options.ExcludePaths, rel) {
+			return false
+		}
+	}
+
+	if len(a.options.IncludeNames) > 0 {
+		matched := false
+		for _, pattern := range a.options.IncludeNames {
+			matched = matched || pattern.MatchString(name)
+		}
+		if !matched {
+			return false
+		}
+	}
+	for _, pattern := range a.options.ExcludeNames {
+		if pattern.MatchString(name) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchesAnyPath(patterns []string, rel string) bool {
+	for _, pattern := range patterns {
+		if matchPath(pattern, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// changed reports whether any line of a given function, from the func keyword to its closing brace, is among
+// the changes in the options. Every function counts as changed if the options don't have any changes.
+func (a *analyzer) changed(f BlanketFunc) bool {
+	if a.options.Changes == nil {
+		return true
+	}
+
+	end := f.DeclPos.Line
+	if f.RBracePos.Line > end {
+		end = f.RBracePos.Line
+	}
+	return a.options.Changes.Touches(f.Filename, f.DeclPos.Line, end)
+}
@@ -0,0 +1,171 @@
+package analysis
+
+import (
+	"go/token"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"gitlab.com/verygoodsoftwarenotvirus/blanket/lib/git"
+	"gitlab.com/verygoodsoftwarenotvirus/blanket/lib/util"
+
+	"github.com/fatih/set"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchPath(t *testing.T) {
+	tests := []struct {
+		pattern, rel string
+		expected     bool
+	}{
+		{"internal/mocks/...", "internal/mocks/store.go", true},
+		{"internal/mocks/...", "internal/mocks/deep/store.go", true},
+		{"internal/mocks/...", "internal/mocksmith/store.go", false},
+		{"./...", "anything/at/all.go", true},
+		{"*_gen.go", "models_gen.go", true},
+		{"*_gen.go", "api/v2/models_gen.go", true},
+		{"*_gen.go", "api/models.go", false},
+		{"*/*_gen.go", "api/models_gen.go", true},
+		{"api", "api/server.go", true},
+		{"api", "api/v2/server.go", false},
+		{"[", "api/server.go", false},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, matchPath(test.pattern, test.rel), "unexpected result for %q against %q", test.pattern, test.rel)
+	}
+}
+
+func TestMatchesAnyPath(t *testing.T) {
+	patterns := []string{"internal/mocks/...", "*_gen.go"}
+
+	assert.True(t, matchesAnyPath(patterns, "internal/mocks/store.go"))
+	assert.True(t, matchesAnyPath(patterns, "api/models_gen.go"))
+	assert.False(t, matchesAnyPath(patterns, "api/server.go"))
+	assert.False(t, matchesAnyPath(nil, "api/server.go"))
+}
+
+func TestRelativePath(t *testing.T) {
+	root := filepath.FromSlash("/src/project")
+
+	t.Run("without a root", func(_t *testing.T) {
+		analyzer := NewAnalyzer()
+		_, ok := analyzer.relativePath(filepath.Join(root, "main.go"))
+		assert.False(t, ok)
+	})
+
+	t.Run("inside the root", func(_t *testing.T) {
+		analyzer := NewAnalyzer()
+		analyzer.SetOptions(Options{Root: root})
+		actual, ok := analyzer.relativePath(filepath.Join(root, "api", "server.go"))
+
+		assert.True(t, ok)
+		assert.Equal(t, "api/server.go", actual)
+	})
+
+	t.Run("outside the root", func(_t *testing.T) {
+		analyzer := NewAnalyzer()
+		analyzer.SetOptions(Options{Root: root})
+		_, ok := analyzer.relativePath(filepath.FromSlash("/src/other/main.go"))
+
+		assert.False(t, ok)
+	})
+}
+
+func TestIncluded(t *testing.T) {
+	root := filepath.FromSlash("/src/project")
+	analyzer := NewAnalyzer()
+	analyzer.SetOptions(Options{
+		Root:         root,
+		IncludePaths: []string{"api/...", "internal/..."},
+		ExcludePaths: []string{"internal/mocks/..."},
+		ExcludeNames: []*regexp.Regexp{regexp.MustCompile(`\.String$`)},
+	})
+
+	assert.True(t, analyzer.included("Serve", filepath.Join(root, "api", "server.go")))
+	assert.False(t, analyzer.included("Thing.String", filepath.Join(root, "api", "server.go")), "expected excluded names to be left out")
+	assert.False(t, analyzer.included("Serve", filepath.Join(root, "cmd", "main.go")), "expected paths that aren't included to be left out")
+	assert.False(t, analyzer.included("Store.Get", filepath.Join(root, "internal", "mocks", "store.go")), "expected excluded paths to be left out")
+
+	analyzer.SetOptions(Options{IncludeNames: []*regexp.Regexp{regexp.MustCompile(`^[A-Z]`)}})
+	assert.True(t, analyzer.included("Serve", filepath.Join(root, "cmd", "main.go")), "expected path patterns to be ignored without a root")
+	assert.False(t, analyzer.included("serve", filepath.Join(root, "cmd", "main.go")), "expected names that aren't included to be left out")
+}
+
+func TestParseScope(t *testing.T) {
+	for input, expected := range map[string]Scope{"": ScopeAll, "all": ScopeAll, "exported": ScopeExported, "unexported": ScopeUnexported} {
+		actual, err := ParseScope(input)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, actual, "unexpected scope for %q", input)
+	}
+
+	_, err := ParseScope("public")
+	assert.Error(t, err)
+}
+
+func TestScopeIncludes(t *testing.T) {
+	tests := []struct {
+		name                 string
+		exported, unexported bool
+	}{
+		{"Function", true, false},
+		{"function", false, true},
+		{"Type.Method", true, false},
+		{"Type.method", false, true},
+		{"kind.Method", false, true},
+	}
+	for _, test := range tests {
+		for s, expected := range map[Scope]bool{ScopeAll: true, ScopeExported: test.exported, ScopeUnexported: test.unexported} {
+			var scope Scope = s
+			assert.Equal(t, expected, scope.includes(test.name), "unexpected %s scope result for %q", scope, test.name)
+		}
+	}
+}
+
+func TestAnalyzeScope(t *testing.T) {
+	examplePath := util.BuildExamplePackagePath(t, "scope", false)
+
+	t.Run("exported", func(_t *testing.T) {
+		analyzer := NewAnalyzer()
+		analyzer.SetOptions(Options{Scope: ScopeExported})
+		actual, err := analyzer.Analyze(examplePath)
+
+		assert.NoError(t, err, "Analyze produced an unexpected error")
+		assert.Equal(t, set.New("Exported.Method", "Function"), actual.Declared)
+
+		output := analyzer.GenerateDiffReport()
+		assert.Equal(t, 50, output.Score)
+		assert.Equal(t, ScopeExported, output.Scope)
+	})
+
+	t.Run("unexported", func(_t *testing.T) {
+		analyzer := NewAnalyzer()
+		analyzer.SetOptions(Options{Scope: ScopeUnexported})
+		actual, err := analyzer.Analyze(examplePath)
+
+		assert.NoError(t, err, "Analyze produced an unexpected error")
+		assert.Equal(t, set.New("Exported.method", "unexported.Method", "function"), actual.Declared)
+		assert.Equal(t, 66, analyzer.GenerateDiffReport().Score)
+	})
+}
+
+func TestChanged(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "main.go")
+	f := BlanketFunc{
+		Filename:  filename,
+		DeclPos:   token.Position{Line: 3},
+		LBracePos: token.Position{Line: 3},
+		RBracePos: token.Position{Line: 6},
+	}
+
+	analyzer := NewAnalyzer()
+	assert.True(t, analyzer.changed(f), "expected every function to count as changed without changes in the options")
+
+	analyzer.SetOptions(Options{Changes: git.Changes{filename: {{Start: 5, End: 5}}}})
+	assert.True(t, analyzer.changed(f))
+
+	analyzer.SetOptions(Options{Changes: git.Changes{filename: {{Start: 7, End: 9}}}})
+	assert.False(t, analyzer.changed(f))
+
+	analyzer.SetOptions(Options{Changes: git.Changes{}})
+	assert.False(t, analyzer.changed(f))
+}
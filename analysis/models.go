@@ -2,30 +2,200 @@ package analysis
 
 import (
 	"go/token"
+	"regexp"
+
+	"gitlab.com/verygoodsoftwarenotvirus/blanket/lib/git"
 
 	"github.com/fatih/set"
 )
 
+// Options controls how an analyzer decides which functions are directly called.
+type Options struct {
+	// TypeCheck loads the package and its tests with full type information and resolves
+	// every call expression in test files to the exact function it targets, instead of
+	// guessing receiver types from declarations and helper return values.
+	TypeCheck bool
+
+	// IgnoreFuncValues stops functions and methods that are referenced without being called
+	// (i.e. passed as an argument or stored in a test table) from counting as directly tested.
+	IgnoreFuncValues bool
+
+	// InterfaceCalls maps method calls made through a variable of interface type to the concrete
+	// types the variable is assigned in tests, and reports the implementations reached that way
+	// separately from the ones that are called directly.
+	InterfaceCalls bool
+
+	// CountInterfaceCalls counts implementations reached through interface calls as directly tested.
+	// It implies InterfaceCalls.
+	CountInterfaceCalls bool
+
+	// Tags are the build tags to honor when deciding which files belong to a package.
+	Tags []string
+
+	// Platform is the GOOS/GOARCH combination to analyze packages for.
+	Platform Platform
+
+	// HelperPatterns are the names of additional functions declared in test files that should be treated as
+	// test helpers, on top of the ones that take a *testing.T, *testing.B, *testing.F or testing.TB, or call
+	// `t.Helper()`.
+	HelperPatterns []*regexp.Regexp
+
+	// IndirectHelperCalls stops functions that tests only call through a test helper from counting as
+	// directly tested. They're still reported separately either way.
+	IndirectHelperCalls bool
+
+	// Root is the directory the path patterns in IncludePaths, ExcludePaths and SuppressionRules are relative to,
+	// usually the root of the module. Path patterns are ignored without one.
+	Root string
+
+	// IncludePaths and ExcludePaths decide which files the analyzed functions can be declared in. A file
+	// counts if it matches any of the IncludePaths (or there are none), and none of the ExcludePaths.
+	IncludePaths []string
+	ExcludePaths []string
+
+	// IncludeNames and ExcludeNames do the same for the names of the analyzed functions, i.e. `Type.Method`.
+	IncludeNames []*regexp.Regexp
+	ExcludeNames []*regexp.Regexp
+
+	// SuppressionRules suppress functions without a directive in their source.
+	SuppressionRules []SuppressionRule
+
+	// Changes limits the analysis to the functions that overlap a changed line, when set (see lib/git).
+	// Functions in files without changes are left out entirely.
+	Changes git.Changes
+
+	// Scope only analyzes the exported or unexported functions, when set.
+	Scope Scope
+
+	// IncludeGenerated analyzes the functions declared in generated files (the ones with a
+	// `// Code generated ... DO NOT EDIT.` comment), which are otherwise skipped and only counted.
+	IncludeGenerated bool
+
+	// Matrix analyzes every package once per platform and merges the results, so that a
+	// function counts as tested only if it is directly tested on every platform it's declared on.
+	Matrix []Platform
+}
+
 type blanketOutput struct {
-	DeclaredCount             int                      `json:"declared"`
-	CalledCount               int                      `json:"called"`
-	Score                     int                      `json:"score"`
-	Details                   map[string][]BlanketFunc `json:"-"`
-	LongestFunctionNameLength int                      `json:"-"`
+	SchemaVersion             int                        `json:"schema_version,omitempty"`
+	Run                       *runMetadata               `json:"run,omitempty"`
+	Package                   string                     `json:"package"`
+	Dir                       string                     `json:"-"`
+	DeclaredCount             int                        `json:"declared"`
+	CalledCount               int                        `json:"called"`
+	Score                     int                        `json:"score"`
+	Scope                     Scope                      `json:"scope,omitempty"`
+	ViaInterfaceCount         int                        `json:"via_interface,omitempty"`
+	ViaHelperCount            int                        `json:"via_helper,omitempty"`
+	Categories                map[string]*categoryOutput `json:"categories,omitempty"`
+	Details                   map[string][]BlanketFunc   `json:"-"`
+	ViaInterface              map[string][]BlanketFunc   `json:"-"`
+	ViaHelper                 map[string][]BlanketFunc   `json:"-"`
+	UntestedOn                map[string][]string        `json:"untested_on,omitempty"`
+	Depths                    map[string]int             `json:"depths,omitempty"`
+	Suppressed                []suppressedOutput         `json:"suppressed,omitempty"`
+	GeneratedCount            int                        `json:"generated,omitempty"`
+	Functions                 []functionOutput           `json:"functions"`
+	LongestFunctionNameLength int                        `json:"-"`
+}
+
+// categoryOutput describes the functions directly called from the test files of a single category,
+// i.e. every test file with a `//go:build integration` constraint.
+type categoryOutput struct {
+	CalledCount int      `json:"called"`
+	Score       int      `json:"score"`
+	Only        []string `json:"only,omitempty"`
+}
+
+// functionOutput is an analyzed function, whether it has a direct unit test, and the tests that reach it. Via is
+// "helper" for functions only test helpers call, and "interface" for the ones tests only call through an interface.
+type functionOutput struct {
+	Name     string   `json:"name"`
+	Receiver string   `json:"receiver,omitempty"`
+	Filename string   `json:"filename"`
+	Line     int      `json:"line"`
+	Column   int      `json:"column"`
+	EndLine  int      `json:"end_line"`
+	Exported bool     `json:"exported"`
+	Tested   bool     `json:"tested"`
+	Via      string   `json:"via,omitempty"`
+	Tests    []string `json:"tests,omitempty"`
+}
+
+// suppressedOutput is a function left out of the report by a suppression directive.
+type suppressedOutput struct {
+	Name     string `json:"name"`
+	Receiver string `json:"receiver,omitempty"`
+	Filename string `json:"filename"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	EndLine  int    `json:"end_line"`
+	Exported bool   `json:"exported"`
+	Reason   string `json:"reason,omitempty"`
+	Until    string `json:"until,omitempty"`
+}
+
+type blanketSummary struct {
+	SchemaVersion  int                        `json:"schema_version,omitempty"`
+	Run            *runMetadata               `json:"run,omitempty"`
+	Packages       []*blanketOutput           `json:"packages"`
+	DeclaredCount  int                        `json:"declared"`
+	CalledCount    int                        `json:"called"`
+	Score          int                        `json:"score"`
+	Scope          Scope                      `json:"scope,omitempty"`
+	GeneratedCount int                        `json:"generated,omitempty"`
+	Platforms      []string                   `json:"platforms,omitempty"`
+	Categories     map[string]*categoryOutput `json:"categories,omitempty"`
 }
 
 type BlanketReport struct {
 	DeclaredDetails map[string]BlanketFunc
 	Called          *set.Set
 	Declared        *set.Set
+
+	// CalledViaInterface holds the functions that tests only reach through an interface method
+	// call. It is nil unless the analyzer was asked to look for interface calls.
+	CalledViaInterface *set.Set
+
+	// CalledViaHelper holds the functions that are only ever called from test helpers (see
+	// isTestHelper) or the functions they call, rather than from a test itself.
+	CalledViaHelper *set.Set
+
+	// CalledByCategory holds the functions directly called from the test files of each category,
+	// keyed by the files' build constraint. Files without one fall into the "unit" category.
+	CalledByCategory map[string]*set.Set
+
+	// CalledBy holds the names of the tests that reach each called function, whether they call it
+	// themselves or through a helper.
+	CalledBy map[string]*set.Set
+
+	// CallDepths holds the minimum number of calls between a test and each function a test reaches,
+	// where 0 means the function is called in the body of a test.
+	CallDepths map[string]int
+
+	// Suppressed holds the functions left out of the report by a `//blanket:ignore` or
+	// `//blanket:ignore-file` directive. They're in neither Declared nor Called.
+	Suppressed map[string]SuppressedFunc
+
+	// Generated holds the functions declared in generated files, which are skipped unless
+	// Options.IncludeGenerated is set. They're in neither Declared nor Called.
+	Generated *set.Set
 }
 
+// BlanketFunc is a declared function. LBracePos and RBracePos are the positions of the opening and closing
+// braces of its body, and are zero for functions without one.
 type BlanketFunc struct {
 	Name      string
 	Filename  string
 	DeclPos   token.Position
-	RBracePos token.Position
 	LBracePos token.Position
+	RBracePos token.Position
+}
+
+// SuppressedFunc is a declared function that a suppression directive leaves out of the report.
+type SuppressedFunc struct {
+	BlanketFunc
+	Suppression
 }
 
 type blanketDetails []BlanketFunc
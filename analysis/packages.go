@@ -0,0 +1,130 @@
+package analysis
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// listedPackage is the subset of `go list -json` output that blanket cares about.
+type listedPackage struct {
+	Dir          string
+	ImportPath   string
+	Name         string
+	GoFiles      []string
+	CgoFiles     []string
+	TestGoFiles  []string
+	XTestGoFiles []string
+	Error        *struct {
+		Err string
+	}
+}
+
+// Platform is a GOOS/GOARCH combination packages can be analyzed for. Empty fields
+// fall back to whatever the go command would use by default.
+type Platform struct {
+	GOOS   string
+	GOARCH string
+}
+
+// ParsePlatform parses a platform in the `goos/goarch` format used by `go tool dist list`.
+func ParsePlatform(in string) (Platform, error) {
+	parts := strings.Split(in, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return Platform{}, fmt.Errorf("invalid platform %q, expected goos/goarch", in)
+	}
+	return Platform{GOOS: parts[0], GOARCH: parts[1]}, nil
+}
+
+func (p Platform) String() string {
+	return fmt.Sprintf("%s/%s", p.GOOS, p.GOARCH)
+}
+
+// environ returns the environment the go command should run with to target the platform.
+func (p Platform) environ() []string {
+	env := os.Environ()
+	if p.GOOS != "" {
+		env = append(env, "GOOS="+p.GOOS)
+	}
+	if p.GOARCH != "" {
+		env = append(env, "GOARCH="+p.GOARCH)
+	}
+	return env
+}
+
+// listPackages resolves the provided package patterns (import paths, relative paths,
+// `./...`-style wildcards, etc.) from within a given directory, exactly the way the
+// go command would. This works both inside and outside of GOPATH. The file lists of
+// every package honor the build tags and platform set in the provided options.
+func listPackages(dir string, opts Options, patterns ...string) ([]listedPackage, error) {
+	args := []string{"list", "-e", "-json"}
+	if len(opts.Tags) > 0 {
+		args = append(args, "-tags="+strings.Join(opts.Tags, ","))
+	}
+	args = append(args, patterns...)
+	cmd := exec.Command("go", args...)
+	cmd.Dir = dir
+	cmd.Env = opts.Platform.environ()
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("running go list: %s", strings.TrimSpace(stderr.String()))
+	}
+
+	var pkgs []listedPackage
+	decoder := json.NewDecoder(&stdout)
+	for {
+		var pkg listedPackage
+		if err := decoder.Decode(&pkg); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, errors.Wrap(err, "decoding go list output")
+		}
+
+		if pkg.Error != nil {
+			return nil, fmt.Errorf("resolving package %s: %s", pkg.ImportPath, pkg.Error.Err)
+		}
+		pkgs = append(pkgs, pkg)
+	}
+
+	if len(pkgs) == 0 {
+		return nil, fmt.Errorf("no packages matched %s", strings.Join(patterns, " "))
+	}
+	return pkgs, nil
+}
+
+// parseListedFiles parses the files go list reported for a given package, grouped by package name the same
+// way parser.ParseDir would group them. Unlike ParseDir, this leaves out every file excluded by build
+// constraints, so platform specific twins of a function never overwrite one another.
+func (a *analyzer) parseListedFiles(pkg listedPackage) (map[string]*ast.Package, error) {
+	var filenames []string
+	for _, files := range [][]string{pkg.GoFiles, pkg.CgoFiles, pkg.TestGoFiles, pkg.XTestGoFiles} {
+		filenames = append(filenames, files...)
+	}
+
+	astPkg := map[string]*ast.Package{}
+	for _, name := range filenames {
+		path := filepath.Join(pkg.Dir, name)
+		f, err := parser.ParseFile(a.fileset, path, nil, parser.AllErrors|parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, ok := astPkg[f.Name.Name]; !ok {
+			astPkg[f.Name.Name] = &ast.Package{Name: f.Name.Name, Files: map[string]*ast.File{}}
+		}
+		astPkg[f.Name.Name].Files[path] = f
+	}
+	return astPkg, nil
+}
@@ -0,0 +1,223 @@
+package analysis
+
+import (
+	"os"
+	"testing"
+
+	"gitlab.com/verygoodsoftwarenotvirus/blanket/lib/util"
+
+	"github.com/fatih/set"
+	"github.com/stretchr/testify/assert"
+)
+
+////////////////////////////////////////////////////////
+//                                                    //
+//               Test Helper Functions                //
+//                                                    //
+////////////////////////////////////////////////////////
+
+func buildExampleModule(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+
+	util.WriteFiles(t, dir, map[string]string{
+		"go.mod":                  "module example.com/thing\n\ngo 1.21\n",
+		"thing.go":                "package thing\n\nfunc A() {}\n\nfunc B() {}\n",
+		"thing_test.go":           "package thing\n\nimport \"testing\"\n\nfunc TestA(t *testing.T) {\n\tA()\n}\n",
+		"sub/sub.go":              "package sub\n\nfunc C() {}\n",
+		"sub/sub_test.go":         "package sub\n\nimport \"testing\"\n\nfunc TestC(t *testing.T) {\n\tC()\n}\n",
+		"testdata/ignored/bad.go": "this is not go code",
+	})
+
+	return dir
+}
+
+////////////////////////////////////////////////////////
+//                                                    //
+//                   Actual Tests                     //
+//                                                    //
+////////////////////////////////////////////////////////
+
+func TestListPackages(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Logf("failing because Getwd returned error: %v", err)
+		t.FailNow()
+	}
+
+	t.Run("import path", func(_t *testing.T) {
+		actual, err := listPackages(wd, Options{}, util.BuildExamplePackagePath(t, "simple", false))
+
+		assert.NoError(t, err)
+		assert.Len(t, actual, 1)
+		assert.Equal(t, util.BuildExamplePackagePath(t, "simple", true), actual[0].Dir)
+		assert.Equal(t, "simple", actual[0].Name)
+		assert.Equal(t, []string{"main.go"}, actual[0].GoFiles)
+		assert.Equal(t, []string{"main_test.go"}, actual[0].TestGoFiles)
+	})
+
+	t.Run("relative path", func(_t *testing.T) {
+		actual, err := listPackages(wd, Options{}, "../example_packages/simple")
+
+		assert.NoError(t, err)
+		assert.Len(t, actual, 1)
+		assert.Equal(t, util.BuildExamplePackagePath(t, "simple", false), actual[0].ImportPath)
+	})
+
+	t.Run("wildcard", func(_t *testing.T) {
+		actual, err := listPackages(wd, Options{}, "../example_packages/...")
+
+		assert.NoError(t, err)
+		assert.True(t, len(actual) > 1, "expected more than one package to match")
+	})
+
+	t.Run("nonexistent package", func(_t *testing.T) {
+		_, err := listPackages(wd, Options{}, util.BuildExamplePackagePath(t, "absolutelynosuchpackage", false))
+		assert.Error(t, err)
+	})
+
+	t.Run("no go files", func(_t *testing.T) {
+		_, err := listPackages(wd, Options{}, "../example_packages/no_go_files")
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid pattern", func(_t *testing.T) {
+		_, err := listPackages(wd, Options{}, "-nope")
+		assert.Error(t, err)
+	})
+
+	t.Run("build tags", func(_t *testing.T) {
+		actual, err := listPackages(wd, Options{Tags: []string{"extra"}}, "../example_packages/platforms")
+
+		assert.NoError(t, err)
+		if assert.Len(t, actual, 1) {
+			assert.Equal(t, []string{"extra.go", "main.go", "path_linux.go"}, actual[0].GoFiles)
+			assert.Equal(t, []string{"extra_test.go", "main_test.go", "path_linux_test.go"}, actual[0].TestGoFiles)
+		}
+	})
+
+	t.Run("platform", func(_t *testing.T) {
+		actual, err := listPackages(wd, Options{Platform: Platform{GOOS: "windows", GOARCH: "amd64"}}, "../example_packages/platforms")
+
+		assert.NoError(t, err)
+		if assert.Len(t, actual, 1) {
+			assert.Equal(t, []string{"main.go", "path_windows.go"}, actual[0].GoFiles)
+			assert.Equal(t, []string{"main_test.go"}, actual[0].TestGoFiles)
+		}
+	})
+
+	t.Run("module outside of GOPATH", func(_t *testing.T) {
+		t.Setenv("GO111MODULE", "on")
+		dir := buildExampleModule(t)
+
+		actual, err := listPackages(dir, Options{}, "./...")
+
+		assert.NoError(t, err)
+		if assert.Len(t, actual, 2) {
+			assert.Equal(t, "example.com/thing", actual[0].ImportPath)
+			assert.Equal(t, "example.com/thing/sub", actual[1].ImportPath)
+		}
+	})
+}
+
+func TestParsePlatform(t *testing.T) {
+	t.Run("normal", func(_t *testing.T) {
+		actual, err := ParsePlatform("linux/arm64")
+
+		assert.NoError(t, err)
+		assert.Equal(t, Platform{GOOS: "linux", GOARCH: "arm64"}, actual)
+		assert.Equal(t, "linux/arm64", actual.String())
+	})
+
+	t.Run("invalid", func(_t *testing.T) {
+		for _, input := range []string{"linux", "linux/", "/amd64", "linux/amd64/v3"} {
+			_, err := ParsePlatform(input)
+			assert.Error(t, err, "expected an error for %q", input)
+		}
+	})
+}
+
+func TestPlatformEnviron(t *testing.T) {
+	t.Run("normal", func(_t *testing.T) {
+		platform := Platform{GOOS: "windows", GOARCH: "arm64"}
+		actual := platform.environ()
+
+		assert.Equal(t, append(os.Environ(), "GOOS=windows", "GOARCH=arm64"), actual)
+	})
+
+	t.Run("zero value", func(_t *testing.T) {
+		platform := Platform{}
+		actual := platform.environ()
+
+		assert.Equal(t, os.Environ(), actual, "expected the go command's own defaults to be left alone")
+	})
+}
+
+func TestParseListedFiles(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Logf("failing because Getwd returned error: %v", err)
+		t.FailNow()
+	}
+
+	t.Run("normal", func(_t *testing.T) {
+		pkgs, err := listPackages(wd, Options{}, "../example_packages/platforms")
+		if err != nil {
+			t.Logf("failing because listPackages returned error: %v", err)
+			t.FailNow()
+		}
+
+		analyzer := NewAnalyzer()
+		actual, err := analyzer.parseListedFiles(pkgs[0])
+
+		assert.NoError(t, err)
+		if assert.Len(t, actual, 1) {
+			assert.Len(t, actual["platforms"].Files, 4, "expected files excluded by build constraints to be skipped")
+		}
+	})
+
+	t.Run("unparseable file", func(_t *testing.T) {
+		analyzer := NewAnalyzer()
+		_, err := analyzer.parseListedFiles(listedPackage{Dir: "../example_packages/platforms", GoFiles: []string{"nope.go"}})
+
+		assert.Error(t, err)
+	})
+}
+
+func TestAnalyzeModule(t *testing.T) {
+	t.Setenv("GO111MODULE", "on")
+	t.Chdir(buildExampleModule(t))
+
+	t.Run("relative path", func(_t *testing.T) {
+		analyzer := NewAnalyzer()
+		actual, err := analyzer.Analyze(".")
+
+		assert.NoError(t, err)
+		assert.Equal(t, set.New("A", "B"), actual.Declared)
+		assert.Equal(t, set.New("A"), actual.Called)
+	})
+
+	t.Run("module import path", func(_t *testing.T) {
+		analyzer := NewAnalyzer()
+		actual, err := analyzer.Analyze("example.com/thing/sub")
+
+		assert.NoError(t, err)
+		assert.Equal(t, set.New("C"), actual.Called)
+	})
+
+	t.Run("with type checking", func(_t *testing.T) {
+		analyzer := NewAnalyzer()
+		analyzer.SetOptions(Options{TypeCheck: true})
+		actual, err := analyzer.Analyze(".")
+
+		assert.NoError(t, err)
+		assert.Equal(t, set.New("A"), actual.Called)
+	})
+
+	t.Run("pattern matching several packages", func(_t *testing.T) {
+		analyzer := NewAnalyzer()
+		_, err := analyzer.Analyze("./...")
+
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,192 @@
+package analysis
+
+import (
+	"go/ast"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/fatih/set"
+)
+
+// testEntryPrefixes are the prefixes `go test` looks for when deciding which functions to run.
+var testEntryPrefixes = []string{"Test", "Benchmark", "Example", "Fuzz"}
+
+// testFunc is a function declared in a test file, along with the file that declares it.
+type testFunc struct {
+	decl *ast.FuncDecl
+	file *ast.File
+}
+
+// reachedFunc is a function declared in a test file that a test reaches, along with how many calls away from the test it
+// is, whether a test helper sits between the two, and the build category of the test.
+type reachedFunc struct {
+	key       string
+	depth     int
+	viaHelper bool
+	category  string
+}
+
+// testingHelperTypes are the types from the testing package whose presence in a function's parameters makes it a test helper.
+var testingHelperTypes = map[string]bool{"T": true, "B": true, "F": true, "TB": true}
+
+// isTestName reports whether a given name is a test name for a given prefix, the same way
+// `go test` decides it: `TestFoo` and `Test` are, `Testimony` isn't.
+func isTestName(name, prefix string) bool {
+	if !strings.HasPrefix(name, prefix) {
+		return false
+	}
+	if len(name) == len(prefix) {
+		return true
+	}
+
+	r, _ := utf8.DecodeRuneInString(name[len(prefix):])
+	return !unicode.IsLower(r)
+}
+
+// isTestEntryPoint reports whether a given function declared in a test file is one the testing
+// package runs by itself. Methods named like tests count too, since suite runners (like testify's)
+// invoke them through reflection rather than through a call we could follow.
+func isTestEntryPoint(in *ast.FuncDecl) bool {
+	name := in.Name.Name
+	if in.Recv != nil {
+		return isTestName(name, "Test")
+	}
+
+	for _, prefix := range testEntryPrefixes {
+		if isTestName(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// isTestHelper reports whether a given function declared in a test file is a test helper: one that takes a
+// *testing.T, *testing.B, *testing.F or testing.TB, calls `t.Helper()`, or has a name matching one of the
+// configured patterns. Test entry points are never helpers.
+func (a *analyzer) isTestHelper(fn testFunc) bool {
+	if isTestEntryPoint(fn.decl) {
+		return false
+	}
+
+	name := a.parseFuncDecl(fn.decl)
+	for _, pattern := range a.options.HelperPatterns {
+		if pattern.MatchString(name) {
+			return true
+		}
+	}
+
+	if testing := importNameOf(fn.file, "testing"); testing != "" {
+		for _, field := range fn.decl.Type.Params.List {
+			t := field.Type
+			if star, ok := t.(*ast.StarExpr); ok {
+				t = star.X
+			}
+			if sel, ok := t.(*ast.SelectorExpr); ok && testingHelperTypes[sel.Sel.Name] {
+				if x, ok := sel.X.(*ast.Ident); ok && x.Name == testing {
+					return true
+				}
+			}
+		}
+	}
+
+	callsHelper := false
+	ast.Inspect(fn.decl.Body, func(n ast.Node) bool {
+		if call, ok := n.(*ast.CallExpr); ok && len(call.Args) == 0 {
+			if sel, ok := call.Fun.(*ast.SelectorExpr); ok && sel.Sel.Name == "Helper" {
+				callsHelper = true
+			}
+		}
+		return !callsHelper
+	})
+	return callsHelper
+}
+
+// importNameOf returns the name a given file refers to an imported package by, or an empty string if
+// the file doesn't import it (or imports it in a way that leaves it without a name).
+func importNameOf(in *ast.File, importPath string) string {
+	for _, imp := range in.Imports {
+		if path, err := strconv.Unquote(imp.Path.Value); err != nil || path != importPath {
+			continue
+		}
+		if imp.Name != nil {
+			if imp.Name.Name == "_" || imp.Name.Name == "." {
+				return ""
+			}
+			return imp.Name.Name
+		}
+		return filepath.Base(importPath)
+	}
+	return ""
+}
+
+// testFuncKey returns the key a function declared in a test file is stored under. Test files
+// of the package itself and of its external test package can each declare a helper with the
+// same name, so the key is qualified with the package name.
+func testFuncKey(pkgName, funcName string) string {
+	return pkgName + "." + funcName
+}
+
+// testFilesOf returns the test files of a given package, sorted by name so that they're always parsed in the same order.
+func testFilesOf(pkg *ast.Package) []*ast.File {
+	var names []string
+	for name := range pkg.Files {
+		if strings.HasSuffix(name, "_test.go") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var files []*ast.File
+	for _, name := range names {
+		files = append(files, pkg.Files[name])
+	}
+	return files
+}
+
+// computeCallDepths returns the minimum number of calls between a test and every declared function a test
+// reaches: 0 for functions called in the body of a test, 1 for the functions called by those (or by a helper
+// the test calls), and so on. Functions no test reaches are left out.
+func (a *analyzer) computeCallDepths(declared *set.Set) map[string]int {
+	depths := map[string]int{}
+	maxDepth := 0
+	for name, depth := range a.callDepths {
+		name = a.promotedMethodName(name)
+		if current, ok := depths[name]; declared.Has(name) && (!ok || depth < current) {
+			depths[name] = depth
+			if depth > maxDepth {
+				maxDepth = depth
+			}
+		}
+	}
+
+	calls := map[string][]string{}
+	for caller, callees := range a.productionCalls {
+		for _, callee := range set.StringSlice(callees) {
+			if callee = a.promotedMethodName(callee); declared.Has(callee) && callee != caller {
+				calls[caller] = append(calls[caller], callee)
+			}
+		}
+	}
+
+	// a breadth first search through the production code, starting from every function the tests reach at once.
+	for depth := 0; depth <= maxDepth; depth++ {
+		for name, d := range depths {
+			if d != depth {
+				continue
+			}
+			for _, callee := range calls[name] {
+				if current, ok := depths[callee]; !ok || depth+1 < current {
+					depths[callee] = depth + 1
+					if depth+1 > maxDepth {
+						maxDepth = depth + 1
+					}
+				}
+			}
+		}
+	}
+	return depths
+}
@@ -0,0 +1,380 @@
+package analysis
+
+import (
+	"go/ast"
+	"regexp"
+	"testing"
+
+	"gitlab.com/verygoodsoftwarenotvirus/blanket/lib/util"
+
+	"github.com/fatih/set"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsTestName(t *testing.T) {
+	tests := map[string]bool{
+		"Test":      true,
+		"TestA":     true,
+		"Test_a":    true,
+		"TestMain":  true,
+		"Testimony": false,
+		"helper":    false,
+	}
+	for input, expected := range tests {
+		assert.Equal(t, expected, isTestName(input, "Test"), "unexpected result for %s", input)
+	}
+}
+
+func TestIsTestEntryPoint(t *testing.T) {
+	codeSample := `
+		package main
+
+		func TestA(t *testing.T) {}
+		func BenchmarkA(b *testing.B) {}
+		func ExampleA() {}
+		func FuzzA(f *testing.F) {}
+		func TestMain(m *testing.M) {}
+		func (s *suite) TestB() {}
+		func Testimony() {}
+		func helper() {}
+		func (s *suite) Benchmark() {}
+	`
+
+	p := parseChunkOfCode(t, codeSample)
+	expected := []bool{true, true, true, true, true, true, false, false, false}
+	for i, d := range p.Decls {
+		fd := d.(*ast.FuncDecl)
+		assert.Equal(t, expected[i], isTestEntryPoint(fd), "unexpected result for %s", fd.Name.Name)
+	}
+}
+
+func TestTestFuncKey(t *testing.T) {
+	assert.Equal(t, "example.setup", testFuncKey("example", "setup"))
+	assert.NotEqual(t, testFuncKey("example", "setup"), testFuncKey("example_test", "setup"), "expected helpers of the external test package to be told apart")
+}
+
+func TestTestFilesOf(t *testing.T) {
+	pkg := &ast.Package{
+		Files: map[string]*ast.File{
+			"b_test.go": {},
+			"a.go":      {},
+			"a_test.go": {},
+		},
+	}
+
+	actual := testFilesOf(pkg)
+
+	if assert.Len(t, actual, 2) {
+		assert.True(t, actual[0] == pkg.Files["a_test.go"], "expected test files to be sorted by name")
+		assert.True(t, actual[1] == pkg.Files["b_test.go"], "expected test files to be sorted by name")
+	}
+}
+
+func TestReachTestFunc(t *testing.T) {
+	analyzer := NewAnalyzer()
+	analyzer.testFuncs["main.setup"] = testFunc{}
+	analyzer.testHelpers.Add("main.setup")
+	analyzer.testPackage = "main"
+	analyzer.testCategory = "integration"
+	analyzer.callDepth = 1
+
+	analyzer.reachTestFunc("setup")
+	analyzer.reachTestFunc("Parse")
+
+	expected := []reachedFunc{{key: "main.setup", depth: 2, viaHelper: true, category: "integration"}}
+	assert.Equal(t, expected, analyzer.reachedTestFuncs, "expected only functions declared in the test files to be queued")
+}
+
+func TestEnterTestFile(t *testing.T) {
+	codeSample := `//go:build integration
+
+		package thing_test
+
+		import "example.com/thing"
+	`
+
+	analyzer := NewAnalyzer()
+	analyzer.importPath = "example.com/thing"
+	analyzer.packageName = "thing"
+
+	analyzer.enterTestFile(parseChunkOfCode(t, codeSample))
+
+	assert.Equal(t, "thing", analyzer.importedAs)
+	assert.Equal(t, "integration", analyzer.testCategory)
+	assert.Equal(t, "thing_test", analyzer.testPackage)
+}
+
+func TestParseTestFunc(t *testing.T) {
+	codeSample := `
+		package main
+
+		import "testing"
+
+		func TestA(t *testing.T) {
+			A()
+			setup(t)
+		}
+
+		func setup(t *testing.T) {}
+	`
+
+	p := parseChunkOfCode(t, codeSample)
+	analyzer := NewAnalyzer()
+	analyzer.testFuncs["main.setup"] = testFunc{decl: p.Decls[2].(*ast.FuncDecl), file: p}
+
+	analyzer.parseTestFunc(testFunc{decl: p.Decls[1].(*ast.FuncDecl), file: p})
+
+	assert.Equal(t, set.New("init", "A", "setup"), analyzer.calledFuncs)
+	assert.Equal(t, "main", analyzer.testPackage)
+	if assert.Len(t, analyzer.reachedTestFuncs, 1) {
+		assert.Equal(t, "main.setup", analyzer.reachedTestFuncs[0].key, "expected helpers to be queued rather than parsed")
+	}
+}
+
+func TestFollowReachedTestFuncs(t *testing.T) {
+	codeSample := `
+		package main
+
+		import "testing"
+
+		func TestA(t *testing.T) {
+			Z()
+		}
+
+		func setup(t *testing.T) {
+			A()
+			build()
+		}
+
+		func build() {
+			B()
+			build()
+		}
+	`
+
+	p := parseChunkOfCode(t, codeSample)
+	analyzer := NewAnalyzer()
+	for _, d := range p.Decls[1:] {
+		fd := d.(*ast.FuncDecl)
+		analyzer.testFuncs[testFuncKey("main", fd.Name.Name)] = testFunc{decl: fd, file: p}
+	}
+	analyzer.reachedTestFuncs = []reachedFunc{
+		{key: "main.TestA", depth: 1},
+		{key: "main.setup", depth: 1, viaHelper: true, category: "integration"},
+	}
+
+	analyzer.followReachedTestFuncs()
+
+	assert.Equal(t, set.New("init", "A", "B", "build"), analyzer.calledFuncs, "expected tests themselves not to be followed")
+	assert.Equal(t, set.New("A", "B", "build"), analyzer.helperCalledFuncs, "expected calls reached through a helper to be told apart")
+	assert.Equal(t, set.New("A", "B", "build"), analyzer.categoryCalledFuncs["integration"], "expected calls to be attributed to the category of the test that reached them")
+	assert.Empty(t, analyzer.reachedTestFuncs)
+	assert.Equal(t, 0, analyzer.callDepth)
+	assert.False(t, analyzer.viaHelper)
+}
+
+func TestGetCalledNamesReachability(t *testing.T) {
+	codeSample := `
+		package main
+
+		import "testing"
+
+		var table = []func(){tableHelper}
+
+		func TestA(t *testing.T) {
+			A()
+			helper(t)
+			t.Run("sub", subtest)
+		}
+
+		func helper(t *testing.T) {
+			B()
+			helper(t)
+		}
+
+		func subtest(t *testing.T) {
+			C()
+		}
+
+		func tableHelper() {
+			D()
+		}
+
+		func unused() {
+			E()
+		}
+	`
+
+	analyzer := NewAnalyzer()
+	analyzer.getCalledNames(parseChunkOfCode(t, codeSample))
+
+	assert.Equal(t, set.New("init", "A", "helper", "B", "C", "D"), analyzer.calledFuncs)
+	assert.Equal(t, set.New("init", "A", "helper", "D"), analyzer.directCalledFuncs)
+	assert.Equal(t, set.New("B", "C", "helper"), analyzer.helperCalledFuncs)
+	assert.Equal(t, set.New("TestA"), analyzer.calledBy["B"])
+	_, ok := analyzer.calledBy["D"]
+	assert.False(t, ok, "expected calls reached from package level values not to be attributed to a test")
+}
+
+func TestGetCalledNamesAttribution(t *testing.T) {
+	helpers := `
+		package main
+
+		import "testing"
+
+		func setup(t *testing.T) {
+			A()
+		}
+	`
+	integration := `//go:build integration
+
+		package main
+
+		import "testing"
+
+		func TestIntegration(t *testing.T) {
+			setup(t)
+		}
+	`
+
+	analyzer := NewAnalyzer()
+	analyzer.getCalledNames(parseChunkOfCode(t, helpers), parseChunkOfCode(t, integration))
+
+	assert.Equal(t, set.New("A", "setup"), analyzer.categoryCalledFuncs["integration"])
+	_, ok := analyzer.categoryCalledFuncs[unitTestCategory]
+	assert.False(t, ok, "expected helper calls to be attributed to the category of the test that makes them")
+	assert.Equal(t, set.New("TestIntegration"), analyzer.calledBy["A"])
+}
+
+func TestIsTestHelper(t *testing.T) {
+	codeSample := `
+		package main
+
+		import (
+			"regexp"
+			tst "testing"
+		)
+
+		func TestA(t *tst.T) {}
+		func takesT(t *tst.T) {}
+		func takesTB(tb tst.TB) {}
+		func takesB(b *tst.B) {}
+		func callsHelper(x interface{ Helper() }) {
+			x.Helper()
+		}
+		func setupThing() {}
+		func newFixture() *fixture { return nil }
+		func takesRegexp(r *regexp.Regexp) {}
+	`
+
+	analyzer := NewAnalyzer()
+	analyzer.SetOptions(Options{HelperPatterns: []*regexp.Regexp{regexp.MustCompile("^setup")}})
+	p := parseChunkOfCode(t, codeSample)
+
+	expected := []bool{false, true, true, true, true, true, false, false}
+	for i, d := range p.Decls[1:] {
+		fd := d.(*ast.FuncDecl)
+		assert.Equal(t, expected[i], analyzer.isTestHelper(testFunc{decl: fd, file: p}), "unexpected result for %s", fd.Name.Name)
+	}
+}
+
+func TestImportNameOf(t *testing.T) {
+	codeSample := `
+		package main
+
+		import (
+			"testing"
+			str "strings"
+			_ "embed"
+			"path/filepath"
+		)
+	`
+
+	p := parseChunkOfCode(t, codeSample)
+
+	assert.Equal(t, "testing", importNameOf(p, "testing"))
+	assert.Equal(t, "str", importNameOf(p, "strings"))
+	assert.Equal(t, "", importNameOf(p, "embed"))
+	assert.Equal(t, "filepath", importNameOf(p, "path/filepath"))
+	assert.Equal(t, "", importNameOf(p, "fmt"))
+}
+
+func TestAnalyzeReachability(t *testing.T) {
+	examplePath := util.BuildExamplePackagePath(t, "reachability", false)
+
+	for name, typeCheck := range map[string]bool{"heuristic": false, "type checked": true} {
+		t.Run(name, func(_t *testing.T) {
+			analyzer := NewAnalyzer()
+			analyzer.SetOptions(Options{TypeCheck: typeCheck})
+			actual, err := analyzer.Analyze(examplePath)
+
+			assert.NoError(t, err, "Analyze produced an unexpected error")
+			assert.Equal(t, set.New("Direct", "ViaHelper", "ViaNestedHelper", "Benchmarked", "Exampled", "Fuzzed", "FromMain"), actual.Called)
+			assert.Equal(t, set.New("ViaHelper", "ViaNestedHelper"), actual.CalledViaHelper)
+			assert.Equal(t, 1, actual.CallDepths["ViaHelper"])
+			assert.Equal(t, 2, actual.CallDepths["ViaNestedHelper"])
+			assert.Equal(t, 0, actual.CallDepths["Direct"])
+			_, ok := actual.CallDepths["Unreached"]
+			assert.False(t, ok, "expected functions no test reaches to be left out")
+
+			output := analyzer.GenerateDiffReport()
+			assert.Equal(t, 2, output.ViaHelperCount)
+			assert.Equal(t, actual.CallDepths, output.Depths)
+		})
+
+		t.Run(name+" with indirect helper calls", func(_t *testing.T) {
+			analyzer := NewAnalyzer()
+			analyzer.SetOptions(Options{TypeCheck: typeCheck, IndirectHelperCalls: true})
+			actual, err := analyzer.Analyze(examplePath)
+
+			assert.NoError(t, err, "Analyze produced an unexpected error")
+			assert.Equal(t, set.New("Direct", "Benchmarked", "Exampled", "Fuzzed", "FromMain"), actual.Called)
+			assert.Equal(t, set.New("ViaHelper", "ViaNestedHelper"), actual.CalledViaHelper)
+			assert.Equal(t, set.New("TestDirect", "TestAlsoDirect"), actual.CalledBy["ViaNestedHelper"])
+		})
+	}
+}
+
+func TestFindProductionCalls(t *testing.T) {
+	codeSample := `
+		package main
+
+		type thing struct{}
+
+		func (t *thing) method() {
+			helper()
+		}
+
+		func helper() {}
+
+		func caller(t *thing) {
+			t.method()
+			helper()
+		}
+	`
+
+	analyzer := NewAnalyzer()
+	analyzer.findStructTypes(parseChunkOfCode(t, codeSample))
+	analyzer.findProductionCalls(parseChunkOfCode(t, codeSample))
+
+	assert.Equal(t, set.New("helper"), analyzer.productionCalls["thing.method"])
+	assert.Equal(t, set.New(), analyzer.productionCalls["helper"])
+	assert.Equal(t, set.New("thing.method", "helper"), analyzer.productionCalls["caller"])
+	assert.Equal(t, set.New("init"), analyzer.calledFuncs, "expected production calls not to count as tested")
+}
+
+func TestComputeCallDepths(t *testing.T) {
+	analyzer := NewAnalyzer()
+	analyzer.callDepths = map[string]int{"a": 0, "b": 2, "undeclared": 0}
+	analyzer.productionCalls = map[string]*set.Set{
+		"a": set.New("b", "c", "a"),
+		"b": set.New("d"),
+		"c": set.New("d"),
+		"e": set.New("a"),
+	}
+
+	expected := map[string]int{"a": 0, "b": 1, "c": 1, "d": 2}
+	actual := analyzer.computeCallDepths(set.New("a", "b", "c", "d", "e"))
+
+	assert.Equal(t, expected, actual)
+}
@@ -0,0 +1,148 @@
+package analysis
+
+import (
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fatih/set"
+)
+
+// SchemaVersion is the version of the JSON report format that schema/report.v1.json describes. Adding a field
+// doesn't change it, while removing one or changing what one means does.
+const SchemaVersion = 1
+
+// Version is the version of blanket that writes a report. Builds set it with
+// `-ldflags "-X gitlab.com/verygoodsoftwarenotvirus/blanket/analysis.Version=..."`.
+var Version = "dev"
+
+// runMetadata describes the run that produced a report.
+type runMetadata struct {
+	Version     string        `json:"version"`
+	GoVersion   string        `json:"go_version"`
+	GeneratedAt time.Time     `json:"generated_at"`
+	Patterns    []string      `json:"patterns,omitempty"`
+	Options     optionsOutput `json:"options"`
+}
+
+// optionsOutput is the part of the options a run was given that affects its results.
+type optionsOutput struct {
+	TypeCheck           bool     `json:"type_check,omitempty"`
+	IgnoreFuncValues    bool     `json:"ignore_func_values,omitempty"`
+	InterfaceCalls      bool     `json:"interface_calls,omitempty"`
+	CountInterfaceCalls bool     `json:"count_interface_calls,omitempty"`
+	Tags                []string `json:"tags,omitempty"`
+	Platform            string   `json:"platform,omitempty"`
+	Matrix              []string `json:"matrix,omitempty"`
+	HelperPatterns      []string `json:"helper_patterns,omitempty"`
+	IndirectHelperCalls bool     `json:"indirect_helper_calls,omitempty"`
+	IncludePaths        []string `json:"include_paths,omitempty"`
+	ExcludePaths        []string `json:"exclude_paths,omitempty"`
+	IncludeNames        []string `json:"include_names,omitempty"`
+	ExcludeNames        []string `json:"exclude_names,omitempty"`
+	SuppressionRules    int      `json:"suppression_rules,omitempty"`
+	ChangesOnly         bool     `json:"changes_only,omitempty"`
+	Scope               Scope    `json:"scope,omitempty"`
+	IncludeGenerated    bool     `json:"include_generated,omitempty"`
+}
+
+// newRunMetadata describes a run with the provided options and package patterns, starting now.
+func newRunMetadata(opts Options, patterns []string) *runMetadata {
+	o := optionsOutput{
+		TypeCheck:           opts.TypeCheck,
+		IgnoreFuncValues:    opts.IgnoreFuncValues,
+		InterfaceCalls:      opts.InterfaceCalls,
+		CountInterfaceCalls: opts.CountInterfaceCalls,
+		Tags:                opts.Tags,
+		HelperPatterns:      regexpStrings(opts.HelperPatterns),
+		IndirectHelperCalls: opts.IndirectHelperCalls,
+		IncludePaths:        opts.IncludePaths,
+		ExcludePaths:        opts.ExcludePaths,
+		IncludeNames:        regexpStrings(opts.IncludeNames),
+		ExcludeNames:        regexpStrings(opts.ExcludeNames),
+		SuppressionRules:    len(opts.SuppressionRules),
+		ChangesOnly:         opts.Changes != nil,
+		Scope:               opts.Scope,
+		IncludeGenerated:    opts.IncludeGenerated,
+	}
+	if opts.Platform != (Platform{}) {
+		o.Platform = opts.Platform.String()
+	}
+	for _, platform := range opts.Matrix {
+		o.Matrix = append(o.Matrix, platform.String())
+	}
+
+	return &runMetadata{
+		Version:     Version,
+		GoVersion:   runtime.Version(),
+		GeneratedAt: time.Now().UTC(),
+		Patterns:    patterns,
+		Options:     o,
+	}
+}
+
+// regexpStrings returns the source text of each of the provided regular expressions.
+func regexpStrings(in []*regexp.Regexp) []string {
+	var out []string
+	for _, r := range in {
+		out = append(out, r.String())
+	}
+	return out
+}
+
+// JSONReport returns what the JSON output of a run holds: the report of its only package, or the summary
+// of every package otherwise, along with the schema version and the metadata of the run.
+func (s *blanketSummary) JSONReport() interface{} {
+	if len(s.Packages) == 1 {
+		pkg := *s.Packages[0]
+		pkg.SchemaVersion, pkg.Run = s.SchemaVersion, s.Run
+		return &pkg
+	}
+	return s
+}
+
+// newFunctionOutput describes where a function with a given name is declared. Methods, whose names
+// look like `Type.Method`, get their receiver type, and only count as exported if their type is too.
+func newFunctionOutput(name string, f BlanketFunc) functionOutput {
+	o := functionOutput{
+		Name:     name,
+		Filename: f.Filename,
+		Line:     f.DeclPos.Line,
+		Column:   f.DeclPos.Column,
+		EndLine:  f.RBracePos.Line,
+		Exported: ScopeExported.includes(name),
+	}
+	if i := strings.Index(name, "."); i >= 0 {
+		o.Receiver = name[:i]
+	}
+	if o.EndLine == 0 {
+		// functions without a body, i.e. ones implemented in assembly, end where they're declared.
+		o.EndLine = o.Line
+	}
+	return o
+}
+
+// functionOutputs describes every function declared in the latest report, sorted by position.
+func (a *analyzer) functionOutputs() []functionOutput {
+	functions := []functionOutput{}
+	for _, name := range set.StringSlice(a.latestReport.Declared) {
+		f := newFunctionOutput(name, a.declaredFuncInfo[name])
+		f.Tested = a.latestReport.Called.Has(name)
+
+		if a.latestReport.CalledViaHelper != nil && a.latestReport.CalledViaHelper.Has(name) {
+			f.Via = "helper"
+		} else if !f.Tested && a.latestReport.CalledViaInterface != nil && a.latestReport.CalledViaInterface.Has(name) {
+			f.Via = "interface"
+		}
+		if tests, ok := a.latestReport.CalledBy[name]; ok {
+			f.Tests = set.StringSlice(tests)
+			sort.Strings(f.Tests)
+		}
+
+		functions = append(functions, f)
+	}
+	sortFunctionOutputs(functions)
+	return functions
+}
@@ -0,0 +1,198 @@
+package analysis
+
+import (
+	"encoding/json"
+	"go/token"
+	"io/ioutil"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"gitlab.com/verygoodsoftwarenotvirus/blanket/lib/git"
+
+	"github.com/fatih/set"
+	"github.com/stretchr/testify/assert"
+)
+
+////////////////////////////////////////////////////////
+//                                                    //
+//               Test Helper Functions                //
+//                                                    //
+////////////////////////////////////////////////////////
+
+// jsonFields returns the names of the JSON fields of a given struct.
+func jsonFields(v interface{}) []string {
+	var fields []string
+	t := reflect.TypeOf(v)
+	for i := 0; i < t.NumField(); i++ {
+		if name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]; name != "-" {
+			fields = append(fields, name)
+		}
+	}
+	return fields
+}
+
+////////////////////////////////////////////////////////
+//                                                    //
+//                   Actual Tests                     //
+//                                                    //
+////////////////////////////////////////////////////////
+
+func TestNewRunMetadata(t *testing.T) {
+	t.Run("normal", func(_t *testing.T) {
+		opts := Options{
+			TypeCheck:      true,
+			Tags:           []string{"integration"},
+			Platform:       Platform{GOOS: "linux", GOARCH: "arm64"},
+			HelperPatterns: []*regexp.Regexp{regexp.MustCompile("^setup")},
+			ExcludePaths:   []string{"internal/**"},
+			Changes:        git.Changes{},
+			Scope:          ScopeExported,
+		}
+
+		actual := newRunMetadata(opts, []string{"./..."})
+
+		assert.Equal(t, Version, actual.Version)
+		assert.NotEmpty(t, actual.GoVersion)
+		assert.False(t, actual.GeneratedAt.IsZero())
+		assert.Equal(t, []string{"./..."}, actual.Patterns)
+		assert.Equal(t, optionsOutput{
+			TypeCheck:      true,
+			Tags:           []string{"integration"},
+			Platform:       "linux/arm64",
+			HelperPatterns: []string{"^setup"},
+			ExcludePaths:   []string{"internal/**"},
+			ChangesOnly:    true,
+			Scope:          ScopeExported,
+		}, actual.Options)
+	})
+
+	t.Run("with a matrix", func(_t *testing.T) {
+		actual := newRunMetadata(Options{Matrix: []Platform{{GOOS: "linux", GOARCH: "amd64"}, {GOOS: "windows", GOARCH: "amd64"}}}, nil)
+
+		assert.Empty(t, actual.Options.Platform)
+		assert.Equal(t, []string{"linux/amd64", "windows/amd64"}, actual.Options.Matrix)
+	})
+}
+
+func TestRegexpStrings(t *testing.T) {
+	t.Run("normal", func(_t *testing.T) {
+		actual := regexpStrings([]*regexp.Regexp{regexp.MustCompile("^setup"), regexp.MustCompile(`\.String$`)})
+		assert.Equal(t, []string{"^setup", `\.String$`}, actual)
+	})
+
+	t.Run("without any", func(_t *testing.T) {
+		assert.Nil(t, regexpStrings(nil), "expected the field to be left out of the JSON output when there aren't any patterns")
+	})
+}
+
+func TestJSONReport(t *testing.T) {
+	run := newRunMetadata(Options{}, nil)
+
+	t.Run("single package", func(_t *testing.T) {
+		summary := &blanketSummary{SchemaVersion: SchemaVersion, Run: run}
+		summary.add(&blanketOutput{Package: "example/api"})
+
+		actual, ok := summary.JSONReport().(*blanketOutput)
+
+		if assert.True(t, ok, "JSONReport should return the report of the only package") {
+			assert.Equal(t, "example/api", actual.Package)
+			assert.Equal(t, SchemaVersion, actual.SchemaVersion)
+			assert.Equal(t, run, actual.Run)
+		}
+		assert.Nil(t, summary.Packages[0].Run, "JSONReport shouldn't modify the summary's packages")
+	})
+
+	t.Run("several packages", func(_t *testing.T) {
+		summary := &blanketSummary{SchemaVersion: SchemaVersion, Run: run}
+		summary.add(&blanketOutput{Package: "example/api"})
+		summary.add(&blanketOutput{Package: "example/store"})
+
+		assert.Equal(t, summary, summary.JSONReport())
+	})
+}
+
+func TestNewFunctionOutput(t *testing.T) {
+	t.Run("method", func(_t *testing.T) {
+		f := BlanketFunc{
+			Name:      "Server.Start",
+			Filename:  "server.go",
+			DeclPos:   token.Position{Line: 10, Column: 1},
+			RBracePos: token.Position{Line: 14, Column: 1},
+		}
+		expected := functionOutput{Name: "Server.Start", Receiver: "Server", Filename: "server.go", Line: 10, Column: 1, EndLine: 14, Exported: true}
+
+		assert.Equal(t, expected, newFunctionOutput(f.Name, f))
+	})
+
+	t.Run("without a body", func(_t *testing.T) {
+		f := BlanketFunc{Name: "add", Filename: "add.go", DeclPos: token.Position{Line: 3, Column: 1}}
+		expected := functionOutput{Name: "add", Filename: "add.go", Line: 3, Column: 1, EndLine: 3}
+
+		assert.Equal(t, expected, newFunctionOutput(f.Name, f))
+	})
+}
+
+func TestFunctionOutputs(t *testing.T) {
+	a := NewAnalyzer()
+	a.declaredFuncInfo = map[string]BlanketFunc{
+		"A": {Name: "A", Filename: "a.go", DeclPos: token.Position{Line: 1, Column: 1}},
+		"B": {Name: "B", Filename: "a.go", DeclPos: token.Position{Line: 5, Column: 1}},
+		"C": {Name: "C", Filename: "a.go", DeclPos: token.Position{Line: 9, Column: 1}},
+	}
+	a.latestReport = &BlanketReport{
+		Declared:           set.New("A", "B", "C"),
+		Called:             set.New("A", "B"),
+		CalledViaHelper:    set.New("B"),
+		CalledViaInterface: set.New("C"),
+		CalledBy:           map[string]*set.Set{"A": set.New("TestZ", "TestA"), "B": set.New("TestB")},
+	}
+
+	expected := []functionOutput{
+		{Name: "A", Filename: "a.go", Line: 1, Column: 1, EndLine: 1, Exported: true, Tested: true, Tests: []string{"TestA", "TestZ"}},
+		{Name: "B", Filename: "a.go", Line: 5, Column: 1, EndLine: 5, Exported: true, Tested: true, Via: "helper", Tests: []string{"TestB"}},
+		{Name: "C", Filename: "a.go", Line: 9, Column: 1, EndLine: 9, Exported: true, Via: "interface"},
+	}
+
+	assert.Equal(t, expected, a.functionOutputs())
+}
+
+func TestReportSchema(t *testing.T) {
+	data, err := ioutil.ReadFile("../schema/report.v1.json")
+	if err != nil {
+		t.Logf("failing because ReadFile returned error: %v", err)
+		t.FailNow()
+	}
+
+	var schema struct {
+		Definitions map[string]struct {
+			Const      int                        `json:"const"`
+			Properties map[string]json.RawMessage `json:"properties"`
+		} `json:"definitions"`
+	}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Logf("failing because the schema isn't valid JSON: %v", err)
+		t.FailNow()
+	}
+
+	assert.Equal(t, SchemaVersion, schema.Definitions["schema_version"].Const)
+
+	definitions := map[string]interface{}{
+		"summary":    blanketSummary{},
+		"package":    blanketOutput{},
+		"function":   functionOutput{},
+		"suppressed": suppressedOutput{},
+		"run":        runMetadata{},
+		"options":    optionsOutput{},
+	}
+	for definition, v := range definitions {
+		fields := jsonFields(v)
+		for _, field := range fields {
+			assert.Contains(t, schema.Definitions[definition].Properties, field, "the schema's %s definition should describe %s", definition, field)
+		}
+		for property := range schema.Definitions[definition].Properties {
+			assert.Contains(t, fields, property, "the schema's %s definition describes %s, which isn't in the report", definition, property)
+		}
+	}
+}
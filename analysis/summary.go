@@ -0,0 +1,317 @@
+package analysis
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/fatih/set"
+	"github.com/pkg/errors"
+)
+
+// calculateScore returns the percentage of declared functions that are directly called. A package
+// that declares no functions at all has nothing left untested, so it receives a perfect score.
+func calculateScore(calledCount, declaredCount int) int {
+	if declaredCount == 0 {
+		return 100
+	}
+	return int(float64(calledCount) / float64(declaredCount) * 100)
+}
+
+// Missing reports whether any package in the summary has functions without direct unit tests.
+func (s *blanketSummary) Missing() bool {
+	return s.CalledCount < s.DeclaredCount
+}
+
+// add includes a given package's results in the summary's totals.
+func (s *blanketSummary) add(output *blanketOutput) {
+	s.Packages = append(s.Packages, output)
+	s.DeclaredCount += output.DeclaredCount
+	s.CalledCount += output.CalledCount
+	s.GeneratedCount += output.GeneratedCount
+
+	for category, c := range output.Categories {
+		if s.Categories == nil {
+			s.Categories = map[string]*categoryOutput{}
+		}
+		if _, ok := s.Categories[category]; !ok {
+			s.Categories[category] = &categoryOutput{}
+		}
+		s.Categories[category].CalledCount += c.CalledCount
+	}
+}
+
+// score calculates the grades for the whole run once every package has been added.
+func (s *blanketSummary) score() {
+	s.Score = calculateScore(s.CalledCount, s.DeclaredCount)
+	for _, c := range s.Categories {
+		c.Score = calculateScore(c.CalledCount, s.DeclaredCount)
+	}
+}
+
+// CategoriesBelow returns the categories of tests whose grade for the whole run falls below a given
+// minimum, sorted by name. A category without any test files has a grade of zero.
+func (s *blanketSummary) CategoriesBelow(minimums map[string]int) []string {
+	var below []string
+	for category, minimum := range minimums {
+		score := 0
+		if c, ok := s.Categories[category]; ok {
+			score = c.Score
+		}
+		if score < minimum {
+			below = append(below, category)
+		}
+	}
+	sort.Strings(below)
+	return below
+}
+
+// DirectoriesBelow returns the directories whose grade falls below a given minimum, sorted by name. Directories are
+// relative to a given root, and their grade covers every package in them or any of their subdirectories.
+func (s *blanketSummary) DirectoriesBelow(root string, minimums map[string]int) []string {
+	var below []string
+	for dir, minimum := range minimums {
+		prefix := filepath.Join(root, filepath.FromSlash(dir))
+		declared, called := 0, 0
+		for _, pkg := range s.Packages {
+			if pkg.Dir == prefix || strings.HasPrefix(pkg.Dir, prefix+string(filepath.Separator)) {
+				declared += pkg.DeclaredCount
+				called += pkg.CalledCount
+			}
+		}
+
+		if calculateScore(called, declared) < minimum {
+			below = append(below, dir)
+		}
+	}
+	sort.Strings(below)
+	return below
+}
+
+// platformReports merges the reports produced for a single package on several platforms.
+type platformReports struct {
+	importPath       string
+	dir              string
+	declaredFuncInfo map[string]BlanketFunc
+	declared         *set.Set
+	viaInterface     *set.Set
+	viaHelper        *set.Set
+	categories       map[string]*set.Set
+	depths           map[string]int
+	calledBy         map[string]*set.Set
+	suppressed       map[string]SuppressedFunc
+	generated        *set.Set
+	untestedOn       map[string][]string
+}
+
+func newPlatformReports(importPath, dir string) *platformReports {
+	return &platformReports{
+		importPath:       importPath,
+		dir:              dir,
+		declaredFuncInfo: map[string]BlanketFunc{},
+		declared:         set.New(),
+		viaHelper:        set.New(),
+		categories:       map[string]*set.Set{},
+		depths:           map[string]int{},
+		calledBy:         map[string]*set.Set{},
+		suppressed:       map[string]SuppressedFunc{},
+		generated:        set.New(),
+		untestedOn:       map[string][]string{},
+	}
+}
+
+// add merges the report produced for a given platform.
+func (p *platformReports) add(platform Platform, report *BlanketReport) {
+	for _, name := range set.StringSlice(report.Declared) {
+		p.declared.Add(name)
+
+		_, seen := p.declaredFuncInfo[name]
+		if !report.Called.Has(name) {
+			p.untestedOn[name] = append(p.untestedOn[name], platform.String())
+			// point at the declaration that's actually missing a test, if it has platform specific twins.
+			seen = seen && len(p.untestedOn[name]) > 1
+		}
+		if !seen {
+			p.declaredFuncInfo[name] = report.DeclaredDetails[name]
+		}
+	}
+
+	for name, depth := range report.CallDepths {
+		if current, ok := p.depths[name]; !ok || depth < current {
+			p.depths[name] = depth
+		}
+	}
+
+	for name, tests := range report.CalledBy {
+		if _, ok := p.calledBy[name]; !ok {
+			p.calledBy[name] = set.New()
+		}
+		p.calledBy[name].Merge(tests)
+	}
+
+	for name, f := range report.Suppressed {
+		p.suppressed[name] = f
+	}
+
+	if report.Generated != nil {
+		p.generated.Merge(report.Generated)
+	}
+
+	if report.CalledViaHelper != nil {
+		p.viaHelper.Merge(report.CalledViaHelper)
+	}
+
+	for category, called := range report.CalledByCategory {
+		if _, ok := p.categories[category]; !ok {
+			p.categories[category] = set.New()
+		}
+		p.categories[category].Merge(called)
+	}
+
+	if report.CalledViaInterface != nil {
+		if p.viaInterface == nil {
+			p.viaInterface = set.New()
+		}
+		p.viaInterface.Merge(report.CalledViaInterface)
+	}
+}
+
+// output summarizes the merged reports. A function only counts as directly tested if
+// it's directly tested on every platform it's declared on.
+func (p *platformReports) output() *blanketOutput {
+	called := set.New()
+	for _, name := range set.StringSlice(p.declared) {
+		if _, ok := p.untestedOn[name]; !ok {
+			called.Add(name)
+		}
+	}
+
+	var viaInterface *set.Set
+	if p.viaInterface != nil {
+		viaInterface = set.New()
+		for _, name := range set.StringSlice(set.Difference(p.viaInterface, called)) {
+			viaInterface.Add(name)
+		}
+	}
+
+	viaHelper := set.New()
+	for _, name := range set.StringSlice(p.viaHelper) {
+		if called.Has(name) {
+			viaHelper.Add(name)
+		}
+	}
+
+	// a category only gets credit for the functions that ended up tested on every platform.
+	categories := map[string]*set.Set{}
+	for category, categoryCalled := range p.categories {
+		categories[category] = set.New()
+		for _, name := range set.StringSlice(categoryCalled) {
+			if called.Has(name) {
+				categories[category].Add(name)
+			}
+		}
+	}
+
+	a := NewAnalyzer()
+	a.importPath = p.importPath
+	a.packageDir = p.dir
+	a.declaredFuncInfo = p.declaredFuncInfo
+	a.latestReport = &BlanketReport{
+		DeclaredDetails:    p.declaredFuncInfo,
+		Declared:           p.declared,
+		Called:             called,
+		CalledViaInterface: viaInterface,
+		CalledViaHelper:    viaHelper,
+		CalledByCategory:   categories,
+		CallDepths:         p.depths,
+		CalledBy:           p.calledBy,
+		Suppressed:         p.suppressed,
+		Generated:          p.generated,
+	}
+
+	output := a.GenerateDiffReport()
+	output.UntestedOn = p.untestedOn
+	return output
+}
+
+// AnalyzePackages analyzes every package matched by the provided patterns (i.e. `./...`) in a
+// single go list invocation, and summarizes the results for the whole run. Each package gets a
+// fresh analyzer, so nothing learned about one package leaks into the analysis of another.
+func AnalyzePackages(opts Options, patterns ...string) (*blanketSummary, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, errors.Wrap(err, "getting current working directory")
+	}
+	return analyzePackagesIn(wd, opts, patterns...)
+}
+
+// analyzePackagesIn does what AnalyzePackages does, resolving the patterns from within a given directory.
+func analyzePackagesIn(dir string, opts Options, patterns ...string) (*blanketSummary, error) {
+	if len(opts.Matrix) > 0 {
+		return analyzeMatrix(dir, opts, patterns...)
+	}
+
+	pkgs, err := listPackages(dir, opts, patterns...)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &blanketSummary{SchemaVersion: SchemaVersion, Run: newRunMetadata(opts, patterns), Scope: opts.Scope}
+	for _, pkg := range pkgs {
+		a := NewAnalyzer()
+		a.SetOptions(opts)
+		if _, err := a.analyzeListedPackage(pkg); err != nil {
+			return nil, errors.Wrapf(err, "analyzing %s", pkg.ImportPath)
+		}
+		summary.add(a.GenerateDiffReport())
+	}
+	summary.score()
+
+	return summary, nil
+}
+
+// analyzeMatrix analyzes the packages matched by the provided patterns once for every platform in
+// the options' matrix, and merges the results for each package.
+func analyzeMatrix(dir string, opts Options, patterns ...string) (*blanketSummary, error) {
+	var importPaths []string
+	merged := map[string]*platformReports{}
+	summary := &blanketSummary{SchemaVersion: SchemaVersion, Run: newRunMetadata(opts, patterns), Scope: opts.Scope}
+
+	for _, platform := range opts.Matrix {
+		platformOpts := opts
+		platformOpts.Platform = platform
+		platformOpts.Matrix = nil
+
+		pkgs, err := listPackages(dir, platformOpts, patterns...)
+		if err != nil {
+			return nil, errors.Wrapf(err, "listing packages for %s", platform)
+		}
+
+		for _, pkg := range pkgs {
+			a := NewAnalyzer()
+			a.SetOptions(platformOpts)
+			report, err := a.analyzeListedPackage(pkg)
+			if err != nil {
+				return nil, errors.Wrapf(err, "analyzing %s for %s", pkg.ImportPath, platform)
+			}
+
+			if _, ok := merged[pkg.ImportPath]; !ok {
+				importPaths = append(importPaths, pkg.ImportPath)
+				merged[pkg.ImportPath] = newPlatformReports(pkg.ImportPath, pkg.Dir)
+			}
+			merged[pkg.ImportPath].add(platform, report)
+		}
+		summary.Platforms = append(summary.Platforms, platform.String())
+	}
+
+	sort.Strings(importPaths)
+	for _, importPath := range importPaths {
+		output := merged[importPath].output()
+		output.Scope = opts.Scope
+		summary.add(output)
+	}
+	summary.score()
+
+	return summary, nil
+}
@@ -0,0 +1,177 @@
+package analysis
+
+import (
+	"path/filepath"
+	"testing"
+
+	"gitlab.com/verygoodsoftwarenotvirus/blanket/lib/util"
+
+	"github.com/fatih/set"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalculateScore(t *testing.T) {
+	t.Run("normal", func(_t *testing.T) {
+		assert.Equal(t, 75, calculateScore(3, 4))
+	})
+
+	t.Run("nothing declared", func(_t *testing.T) {
+		assert.Equal(t, 100, calculateScore(0, 0))
+	})
+}
+
+func TestBlanketSummaryMissing(t *testing.T) {
+	missing := &blanketSummary{DeclaredCount: 4, CalledCount: 3}
+	assert.True(t, missing.Missing())
+
+	perfect := &blanketSummary{DeclaredCount: 4, CalledCount: 4}
+	assert.False(t, perfect.Missing())
+}
+
+func TestBlanketSummaryCategoriesBelow(t *testing.T) {
+	summary := &blanketSummary{}
+	summary.add(&blanketOutput{
+		DeclaredCount: 4,
+		CalledCount:   3,
+		Categories: map[string]*categoryOutput{
+			"unit":        {CalledCount: 1},
+			"integration": {CalledCount: 3},
+		},
+	})
+	summary.score()
+
+	assert.Equal(t, 25, summary.Categories["unit"].Score)
+	assert.Equal(t, 75, summary.Categories["integration"].Score)
+	assert.Empty(t, summary.CategoriesBelow(map[string]int{"unit": 25, "integration": 50}))
+	assert.Equal(t, []string{"e2e", "unit"}, summary.CategoriesBelow(map[string]int{"unit": 50, "e2e": 1}))
+}
+
+func TestBlanketSummaryDirectoriesBelow(t *testing.T) {
+	summary := &blanketSummary{}
+	summary.add(&blanketOutput{Dir: filepath.FromSlash("/src/project/api"), DeclaredCount: 4, CalledCount: 4})
+	summary.add(&blanketOutput{Dir: filepath.FromSlash("/src/project/internal/legacy"), DeclaredCount: 4, CalledCount: 1})
+	summary.add(&blanketOutput{Dir: filepath.FromSlash("/src/project/internal/legacyish"), DeclaredCount: 4, CalledCount: 4})
+	summary.score()
+
+	root := filepath.FromSlash("/src/project")
+	assert.Empty(t, summary.DirectoriesBelow(root, map[string]int{"api": 100, "internal": 60, "nonexistent": 100}))
+	assert.Equal(t, []string{"internal/legacy"}, summary.DirectoriesBelow(root, map[string]int{"internal/legacy": 30, "api": 90}))
+	assert.Equal(t, []string{"."}, summary.DirectoriesBelow(root, map[string]int{".": 80}))
+}
+
+func TestPlatformReports(t *testing.T) {
+	linux := Platform{GOOS: "linux", GOARCH: "amd64"}
+	windows := Platform{GOOS: "windows", GOARCH: "amd64"}
+
+	p := newPlatformReports("example", "/src/example")
+	p.add(linux, &BlanketReport{
+		DeclaredDetails: map[string]BlanketFunc{
+			"A": {Name: "A", Filename: "a.go"},
+			"B": {Name: "B", Filename: "b_linux.go"},
+		},
+		Declared: set.New("A", "B"),
+		Called:   set.New("A", "B"),
+	})
+	p.add(windows, &BlanketReport{
+		DeclaredDetails: map[string]BlanketFunc{
+			"A": {Name: "A", Filename: "a.go"},
+			"B": {Name: "B", Filename: "b_windows.go"},
+			"C": {Name: "C", Filename: "c_windows.go"},
+		},
+		Declared:  set.New("A", "B", "C"),
+		Called:    set.New("A"),
+		Generated: set.New("C.String"),
+	})
+
+	actual := p.output()
+
+	assert.Equal(t, "example", actual.Package)
+	assert.Equal(t, "/src/example", actual.Dir)
+	assert.Equal(t, 3, actual.DeclaredCount)
+	assert.Equal(t, 1, actual.CalledCount)
+	assert.Equal(t, 1, actual.GeneratedCount)
+	assert.Equal(t, map[string][]string{"B": {"windows/amd64"}, "C": {"windows/amd64"}}, actual.UntestedOn)
+	if assert.Len(t, actual.Details["b_windows.go"], 1, "expected the untested twin to be reported") {
+		assert.Equal(t, "B", actual.Details["b_windows.go"][0].Name)
+	}
+}
+
+func TestAnalyzePackages(t *testing.T) {
+	t.Run("several packages", func(_t *testing.T) {
+		actual, err := AnalyzePackages(
+			Options{},
+			util.BuildExamplePackagePath(t, "simple", false),
+			util.BuildExamplePackagePath(t, "perfect", false),
+		)
+
+		assert.NoError(t, err)
+		if assert.Len(t, actual.Packages, 2) {
+			assert.Equal(t, util.BuildExamplePackagePath(t, "simple", false), actual.Packages[0].Package)
+			assert.Equal(t, 75, actual.Packages[0].Score)
+			assert.Equal(t, util.BuildExamplePackagePath(t, "perfect", false), actual.Packages[1].Package)
+			assert.Equal(t, 100, actual.Packages[1].Score)
+		}
+		assert.Equal(t, 8, actual.DeclaredCount)
+		assert.Equal(t, 7, actual.CalledCount)
+		assert.Equal(t, 87, actual.Score)
+	})
+
+	t.Run("wildcard", func(_t *testing.T) {
+		actual, err := AnalyzePackages(Options{}, "../example_packages/p...")
+
+		assert.NoError(t, err)
+		assert.True(t, len(actual.Packages) > 1, "expected more than one package to be analyzed")
+	})
+
+	t.Run("nonexistent package", func(_t *testing.T) {
+		_, err := AnalyzePackages(Options{}, util.BuildExamplePackagePath(t, "absolutelynosuchpackage", false))
+		assert.Error(t, err)
+	})
+
+	t.Run("platform", func(_t *testing.T) {
+		actual, err := AnalyzePackages(
+			Options{Platform: Platform{GOOS: "windows", GOARCH: "amd64"}},
+			util.BuildExamplePackagePath(t, "platforms", false),
+		)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 2, actual.DeclaredCount)
+		assert.Equal(t, 1, actual.CalledCount)
+	})
+
+	t.Run("matrix", func(_t *testing.T) {
+		actual, err := AnalyzePackages(
+			Options{Matrix: []Platform{{GOOS: "linux", GOARCH: "amd64"}, {GOOS: "windows", GOARCH: "amd64"}}},
+			util.BuildExamplePackagePath(t, "platforms", false),
+		)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"linux/amd64", "windows/amd64"}, actual.Platforms)
+		if assert.Len(t, actual.Packages, 1) {
+			assert.Equal(t, map[string][]string{"pathSeparator": {"windows/amd64"}}, actual.Packages[0].UntestedOn)
+		}
+		assert.Equal(t, 2, actual.DeclaredCount)
+		assert.Equal(t, 1, actual.CalledCount)
+	})
+}
+
+func TestAnalyzeMatrix(t *testing.T) {
+	matrix := []Platform{{GOOS: "linux", GOARCH: "amd64"}, {GOOS: "windows", GOARCH: "amd64"}}
+
+	t.Run("normal", func(_t *testing.T) {
+		actual, err := analyzeMatrix(".", Options{Matrix: matrix}, "../example_packages/platforms")
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"linux/amd64", "windows/amd64"}, actual.Platforms)
+		if assert.Len(t, actual.Packages, 1) {
+			assert.Equal(t, "gitlab.com/verygoodsoftwarenotvirus/blanket/example_packages/platforms", actual.Packages[0].Package)
+			assert.Equal(t, map[string][]string{"pathSeparator": {"windows/amd64"}}, actual.Packages[0].UntestedOn)
+		}
+		assert.Equal(t, 50, actual.Score)
+	})
+
+	t.Run("nonexistent package", func(_t *testing.T) {
+		_, err := analyzeMatrix(".", Options{Matrix: matrix}, "../example_packages/absolutelynosuchpackage")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,150 @@
+package analysis
+
+import (
+	"fmt"
+	"go/ast"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// ignoreDirective leaves the function whose doc comment it's in out of the report, i.e. `//blanket:ignore trivial`.
+	ignoreDirective = "//blanket:ignore"
+	// ignoreFileDirective leaves every function declared in the file it's in out of the report. It only counts
+	// before the package clause.
+	ignoreFileDirective = "//blanket:ignore-file"
+	// untilLayout is the format of the expiry date a suppression directive can have, i.e. `until=2027-01-01`.
+	untilLayout = "2006-01-02"
+)
+
+// Suppression is the reason a function was left out of a report, along with the last day it applies on.
+// A zero Until means the suppression never expires.
+type Suppression struct {
+	Reason string
+	Until  time.Time
+}
+
+// expired reports whether a suppression no longer applies at a given moment. Suppressions
+// apply through the end of the day they're set to expire on.
+func (s Suppression) expired(now time.Time) bool {
+	return !s.Until.IsZero() && !now.Before(s.Until.AddDate(0, 0, 1))
+}
+
+// SuppressionRule suppresses every function whose name matches Name (if set) declared in a file whose path matches
+// Path (if set, see Options.Root), the same way a `//blanket:ignore` directive would.
+type SuppressionRule struct {
+	Name *regexp.Regexp
+	Path string
+	Suppression
+}
+
+// parseSuppression parses the text of a comment carrying a given suppression directive. It returns
+// false if the comment is another comment altogether, and an error if its expiry date is invalid.
+func parseSuppression(directive, comment string) (Suppression, bool, error) {
+	if !strings.HasPrefix(comment, directive) {
+		return Suppression{}, false, nil
+	}
+	rest := comment[len(directive):]
+	if rest != "" && rest[0] != ' ' && rest[0] != '\t' {
+		// another directive that happens to share the prefix, i.e. `//blanket:ignore-file` for `//blanket:ignore`.
+		return Suppression{}, false, nil
+	}
+
+	var s Suppression
+	var reason []string
+	for _, field := range strings.Fields(rest) {
+		if !strings.HasPrefix(field, "until=") {
+			reason = append(reason, field)
+			continue
+		}
+
+		until, err := time.ParseInLocation(untilLayout, strings.TrimPrefix(field, "until="), time.Local)
+		if err != nil {
+			return Suppression{}, true, fmt.Errorf("invalid expiry date %q, expected until=YYYY-MM-DD", field)
+		}
+		s.Until = until
+	}
+	s.Reason = strings.Join(reason, " ")
+	return s, true, nil
+}
+
+// findSuppression returns the suppression directive among a group of comments, if there is one.
+func (a *analyzer) findSuppression(directive string, groups ...*ast.CommentGroup) (*Suppression, error) {
+	for _, group := range groups {
+		if group == nil {
+			continue
+		}
+		for _, c := range group.List {
+			s, ok, err := parseSuppression(directive, c.Text)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", a.fileset.Position(c.Slash), err)
+			}
+			if ok {
+				return &s, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// headerComments returns the comments before a file's package clause, which is the only place a file-level directive
+// reads as one.
+func headerComments(in *ast.File) []*ast.CommentGroup {
+	var header []*ast.CommentGroup
+	for _, group := range in.Comments {
+		if group.Pos() < in.Package {
+			header = append(header, group)
+		}
+	}
+	return header
+}
+
+// suppressedOutputs lists a report's suppressed functions the way they're rendered, sorted by position.
+func suppressedOutputs(in map[string]SuppressedFunc) []suppressedOutput {
+	var out []suppressedOutput
+	for _, f := range in {
+		fo := newFunctionOutput(f.Name, f.BlanketFunc)
+		o := suppressedOutput{
+			Name:     fo.Name,
+			Receiver: fo.Receiver,
+			Filename: fo.Filename,
+			Line:     fo.Line,
+			Column:   fo.Column,
+			EndLine:  fo.EndLine,
+			Exported: fo.Exported,
+			Reason:   f.Reason,
+		}
+		if !f.Until.IsZero() {
+			o.Until = f.Until.Format(untilLayout)
+		}
+		out = append(out, o)
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Filename != out[j].Filename {
+			return out[i].Filename < out[j].Filename
+		}
+		return out[i].Line < out[j].Line
+	})
+	return out
+}
+
+// suppressionRuleFor returns the suppression of the first rule in the options that applies to a given function.
+func (a *analyzer) suppressionRuleFor(name, filename string) *Suppression {
+	for _, rule := range a.options.SuppressionRules {
+		if rule.Name != nil && !rule.Name.MatchString(name) {
+			continue
+		}
+		if rule.Path != "" {
+			if rel, ok := a.relativePath(filename); !ok || !matchPath(rule.Path, rel) {
+				continue
+			}
+		}
+
+		s := rule.Suppression
+		return &s
+	}
+	return nil
+}
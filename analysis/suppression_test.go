@@ -0,0 +1,265 @@
+package analysis
+
+import (
+	"go/ast"
+	"go/token"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"gitlab.com/verygoodsoftwarenotvirus/blanket/lib/util"
+
+	"github.com/fatih/set"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSuppression(t *testing.T) {
+	t.Run("with reason", func(_t *testing.T) {
+		actual, ok, err := parseSuppression(ignoreDirective, "//blanket:ignore trivial wrapper")
+
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, Suppression{Reason: "trivial wrapper"}, actual)
+	})
+
+	t.Run("with expiry date", func(_t *testing.T) {
+		actual, ok, err := parseSuppression(ignoreDirective, "//blanket:ignore until=2027-01-01 flaky")
+
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, "flaky", actual.Reason)
+		assert.Equal(t, time.Date(2027, 1, 1, 0, 0, 0, 0, time.Local), actual.Until)
+	})
+
+	t.Run("without reason", func(_t *testing.T) {
+		actual, ok, err := parseSuppression(ignoreFileDirective, "//blanket:ignore-file")
+
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, Suppression{}, actual)
+	})
+
+	t.Run("other comments", func(_t *testing.T) {
+		for _, comment := range []string{"// just a comment", "//blanket:ignore-file", "//blanket:ignored"} {
+			_, ok, err := parseSuppression(ignoreDirective, comment)
+			assert.NoError(t, err)
+			assert.False(t, ok, "expected %q not to be an ignore directive", comment)
+		}
+	})
+
+	t.Run("invalid expiry date", func(_t *testing.T) {
+		_, ok, err := parseSuppression(ignoreDirective, "//blanket:ignore until=soon")
+
+		assert.Error(t, err)
+		assert.True(t, ok)
+	})
+}
+
+func TestSuppressionExpired(t *testing.T) {
+	s := Suppression{Until: time.Date(2027, 1, 1, 0, 0, 0, 0, time.Local)}
+
+	assert.False(t, s.expired(time.Date(2026, 12, 31, 12, 0, 0, 0, time.Local)))
+	assert.False(t, s.expired(time.Date(2027, 1, 1, 23, 59, 0, 0, time.Local)), "expected suppressions to apply through the day they expire on")
+	assert.True(t, s.expired(time.Date(2027, 1, 2, 0, 0, 0, 0, time.Local)))
+	assert.False(t, Suppression{}.expired(time.Date(3000, 1, 1, 0, 0, 0, 0, time.Local)), "expected suppressions without an expiry date to never expire")
+}
+
+func TestGetDeclaredNamesWithSuppressions(t *testing.T) {
+	t.Run("function directives", func(_t *testing.T) {
+		codeSample := `
+			package main
+
+			func a() {}
+
+			// b is ignored.
+			//blanket:ignore trivial
+			func b() {}
+
+			//blanket:ignore until=2020-01-01
+			func c() {}
+		`
+
+		analyzer := NewAnalyzer()
+		err := analyzer.getDeclaredNames(parseChunkOfCode(t, codeSample))
+
+		assert.NoError(t, err)
+		assert.Len(t, analyzer.declaredFuncInfo, 2)
+		assert.Contains(t, analyzer.declaredFuncInfo, "a")
+		assert.Contains(t, analyzer.declaredFuncInfo, "c")
+		if assert.Len(t, analyzer.suppressedFuncs, 1) {
+			assert.Equal(t, "trivial", analyzer.suppressedFuncs["b"].Reason)
+			assert.Equal(t, "b", analyzer.suppressedFuncs["b"].Name)
+		}
+	})
+
+	t.Run("file directive", func(_t *testing.T) {
+		codeSample := `//blanket:ignore-file generated by hand
+
+			package main
+
+			func a() {}
+
+			//blanket:ignore its own reason
+			func b() {}
+		`
+
+		analyzer := NewAnalyzer()
+		err := analyzer.getDeclaredNames(parseChunkOfCode(t, codeSample))
+
+		assert.NoError(t, err)
+		assert.Empty(t, analyzer.declaredFuncInfo)
+		assert.Equal(t, "generated by hand", analyzer.suppressedFuncs["a"].Reason)
+		assert.Equal(t, "its own reason", analyzer.suppressedFuncs["b"].Reason)
+	})
+
+	t.Run("file directive after the package clause", func(_t *testing.T) {
+		codeSample := `package main
+
+			//blanket:ignore-file too late
+
+			func a() {
+				//blanket:ignore-file inside of a function
+			}
+		`
+
+		analyzer := NewAnalyzer()
+		err := analyzer.getDeclaredNames(parseChunkOfCode(t, codeSample))
+
+		assert.NoError(t, err)
+		assert.Contains(t, analyzer.declaredFuncInfo, "a")
+		assert.Empty(t, analyzer.suppressedFuncs)
+	})
+
+	t.Run("invalid directive", func(_t *testing.T) {
+		codeSample := `
+			package main
+
+			//blanket:ignore until=tomorrow
+			func a() {}
+		`
+
+		analyzer := NewAnalyzer()
+		err := analyzer.getDeclaredNames(parseChunkOfCode(t, codeSample))
+
+		assert.Error(t, err)
+	})
+}
+
+func TestFindSuppression(t *testing.T) {
+	analyzer := NewAnalyzer()
+	p := parseChunkOfCode(t, `
+		package main
+
+		// a does things.
+		//blanket:ignore because
+		func a() {}
+	`)
+
+	actual, err := analyzer.findSuppression(ignoreDirective, nil, p.Decls[0].(*ast.FuncDecl).Doc)
+
+	assert.NoError(t, err)
+	if assert.NotNil(t, actual) {
+		assert.Equal(t, "because", actual.Reason)
+	}
+}
+
+func TestHeaderComments(t *testing.T) {
+	t.Run("normal", func(_t *testing.T) {
+		p := parseChunkOfCode(t, `
+			// Code generated by hand. DO NOT EDIT.
+
+			//blanket:ignore-file because
+			package main
+
+			//blanket:ignore-file too late
+			func a() {}
+		`)
+
+		actual := headerComments(p)
+
+		if assert.Len(t, actual, 2) {
+			assert.Equal(t, "//blanket:ignore-file because", actual[1].List[0].Text)
+		}
+	})
+
+	t.Run("without any", func(_t *testing.T) {
+		p := parseChunkOfCode(t, `
+			package main
+
+			// a does things.
+			func a() {}
+		`)
+
+		assert.Empty(t, headerComments(p))
+	})
+}
+
+func TestSuppressedOutputs(t *testing.T) {
+	input := map[string]SuppressedFunc{
+		"b": {
+			BlanketFunc: BlanketFunc{Name: "b", Filename: "a.go", DeclPos: token.Position{Line: 7}},
+			Suppression: Suppression{Reason: "because", Until: time.Date(2027, 1, 1, 0, 0, 0, 0, time.Local)},
+		},
+		"a":       {BlanketFunc: BlanketFunc{Name: "a", Filename: "a.go", DeclPos: token.Position{Line: 3}}},
+		"Thing.C": {BlanketFunc: BlanketFunc{Name: "Thing.C", Filename: "b.go", DeclPos: token.Position{Line: 1, Column: 1}, RBracePos: token.Position{Line: 4}}},
+	}
+
+	expected := []suppressedOutput{
+		{Name: "a", Filename: "a.go", Line: 3, EndLine: 3},
+		{Name: "b", Filename: "a.go", Line: 7, EndLine: 7, Reason: "because", Until: "2027-01-01"},
+		{Name: "Thing.C", Receiver: "Thing", Filename: "b.go", Line: 1, Column: 1, EndLine: 4, Exported: true},
+	}
+	actual := suppressedOutputs(input)
+
+	assert.Equal(t, expected, actual)
+}
+
+func TestAnalyzeSuppressions(t *testing.T) {
+	examplePath := util.BuildExamplePackagePath(t, "suppressed", false)
+
+	t.Run("normal", func(_t *testing.T) {
+		analyzer := NewAnalyzer()
+		actual, err := analyzer.Analyze(examplePath)
+
+		assert.NoError(t, err, "Analyze produced an unexpected error")
+		assert.Equal(t, set.New("Tested", "Expired"), actual.Declared)
+		assert.Equal(t, set.New("Tested"), actual.Called)
+		assert.Len(t, actual.Suppressed, 3)
+		assert.Equal(t, "thin wrappers around the os package", actual.Suppressed["hostname"].Reason)
+
+		output := analyzer.GenerateDiffReport()
+		assert.Equal(t, 50, output.Score)
+		assert.Len(t, output.Suppressed, 3)
+	})
+
+	t.Run("before the expiry date", func(_t *testing.T) {
+		analyzer := NewAnalyzer()
+		analyzer.now = func() time.Time { return time.Date(2019, 6, 1, 0, 0, 0, 0, time.Local) }
+		actual, err := analyzer.Analyze(examplePath)
+
+		assert.NoError(t, err, "Analyze produced an unexpected error")
+		assert.Equal(t, set.New("Tested"), actual.Declared)
+		assert.Contains(t, actual.Suppressed, "Expired")
+	})
+}
+
+func TestSuppressionRuleFor(t *testing.T) {
+	root := filepath.FromSlash("/src/project")
+	analyzer := NewAnalyzer()
+	analyzer.SetOptions(Options{
+		Root: root,
+		SuppressionRules: []SuppressionRule{
+			{Name: regexp.MustCompile(`\.String$`), Suppression: Suppression{Reason: "trivial"}},
+			{Path: "internal/osutil/...", Suppression: Suppression{Reason: "thin wrappers"}},
+		},
+	})
+
+	if actual := analyzer.suppressionRuleFor("Thing.String", filepath.Join(root, "thing.go")); assert.NotNil(t, actual) {
+		assert.Equal(t, "trivial", actual.Reason)
+	}
+	if actual := analyzer.suppressionRuleFor("Hostname", filepath.Join(root, "internal", "osutil", "host.go")); assert.NotNil(t, actual) {
+		assert.Equal(t, "thin wrappers", actual.Reason)
+	}
+	assert.Nil(t, analyzer.suppressionRuleFor("Thing.Run", filepath.Join(root, "thing.go")))
+}
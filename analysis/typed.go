@@ -0,0 +1,441 @@
+package analysis
+
+import (
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/fatih/set"
+	"github.com/pkg/errors"
+)
+
+// packageImporter wraps a source importer so that external test packages
+// (package foo_test) receive the very same *types.Package that we checked
+// for foo, rather than a second copy loaded from disk. Without this, objects
+// referenced from black-box tests would never compare equal to the ones we
+// declared.
+type packageImporter struct {
+	base types.ImporterFrom
+	pkg  *types.Package
+}
+
+func (p *packageImporter) Import(path string) (*types.Package, error) {
+	return p.ImportFrom(path, "", 0)
+}
+
+func (p *packageImporter) ImportFrom(path, dir string, mode types.ImportMode) (*types.Package, error) {
+	if p.pkg != nil && path == p.pkg.Path() {
+		return p.pkg, nil
+	}
+	return p.base.ImportFrom(path, dir, mode)
+}
+
+// buildContext returns the build context the packages analyzed with the provided options are built with, which
+// honors their build tags and platform. Like the go command, it turns cgo off when building for another platform.
+func buildContext(opts Options) *build.Context {
+	ctxt := build.Default
+	ctxt.BuildTags = append([]string(nil), opts.Tags...)
+	if opts.Platform.GOOS != "" {
+		ctxt.GOOS = opts.Platform.GOOS
+	}
+	if opts.Platform.GOARCH != "" {
+		ctxt.GOARCH = opts.Platform.GOARCH
+	}
+	if ctxt.GOOS != build.Default.GOOS || ctxt.GOARCH != build.Default.GOARCH {
+		ctxt.CgoEnabled = false
+	}
+	return &ctxt
+}
+
+// sourceImporter type checks imported packages from source, the way go/importer's "source" importer does, except
+// that it picks their files with a given build context rather than always using build.Default.
+type sourceImporter struct {
+	ctxt     *build.Context
+	fileset  *token.FileSet
+	packages map[string]*types.Package
+}
+
+func newSourceImporter(ctxt *build.Context, fileset *token.FileSet) *sourceImporter {
+	return &sourceImporter{ctxt: ctxt, fileset: fileset, packages: map[string]*types.Package{}}
+}
+
+func (s *sourceImporter) Import(path string) (*types.Package, error) {
+	return s.ImportFrom(path, "", 0)
+}
+
+func (s *sourceImporter) ImportFrom(path, dir string, mode types.ImportMode) (*types.Package, error) {
+	if path == "unsafe" {
+		return types.Unsafe, nil
+	}
+
+	bp, err := s.ctxt.Import(path, dir, 0)
+	if err != nil {
+		return nil, errors.Wrapf(err, "importing %s", path)
+	}
+	// packages are keyed by directory, since vendoring can give the same import path to several of them.
+	if pkg, ok := s.packages[bp.Dir]; ok {
+		if !pkg.Complete() {
+			return nil, fmt.Errorf("import cycle through package %q", bp.ImportPath)
+		}
+		return pkg, nil
+	}
+
+	var files []*ast.File
+	for _, name := range append(bp.GoFiles, bp.CgoFiles...) {
+		f, err := parser.ParseFile(s.fileset, filepath.Join(bp.Dir, name), nil, 0)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing %s", bp.ImportPath)
+		}
+		files = append(files, f)
+	}
+
+	pkg := types.NewPackage(bp.ImportPath, bp.Name)
+	s.packages[bp.Dir] = pkg
+
+	var firstErr error
+	conf := &types.Config{
+		Importer:         s,
+		IgnoreFuncBodies: true,
+		// cgo isn't run, so references to package C can't be checked.
+		FakeImportC: true,
+		Sizes:       types.SizesFor("gc", s.ctxt.GOARCH),
+		Error: func(err error) {
+			if firstErr == nil {
+				firstErr = err
+			}
+		},
+	}
+	types.NewChecker(conf, s.fileset, pkg, nil).Files(files)
+	if firstErr != nil {
+		return nil, errors.Wrapf(firstErr, "type checking %s", bp.ImportPath)
+	}
+	pkg.MarkComplete()
+	return pkg, nil
+}
+
+// typedFuncName returns the name blanket uses to refer to a given function. It matches the
+// format produced by parseFuncDecl, i.e. `Type.Method` for methods and `Function` otherwise.
+func typedFuncName(fn *types.Func) string {
+	sig, ok := fn.Type().(*types.Signature)
+	if !ok || sig.Recv() == nil {
+		return fn.Name()
+	}
+
+	recv := sig.Recv().Type()
+	if p, ok := recv.(*types.Pointer); ok {
+		recv = p.Elem()
+	}
+	if n, ok := recv.(*types.Named); ok {
+		return fmt.Sprintf("%s.%s", n.Origin().Obj().Name(), fn.Name())
+	}
+	return fn.Name()
+}
+
+// calleeOf returns the function a given call expression targets, or nil if the call is
+// not a static call of a declared function (e.g. a builtin, a type conversion or a call
+// of a function value).
+func calleeOf(info *types.Info, call *ast.CallExpr) *types.Func {
+	fun := ast.Unparen(call.Fun)
+	switch f := fun.(type) {
+	case *ast.IndexExpr:
+		fun = ast.Unparen(f.X)
+	case *ast.IndexListExpr:
+		fun = ast.Unparen(f.X)
+	}
+
+	var obj types.Object
+	switch f := fun.(type) {
+	case *ast.Ident:
+		obj = info.Uses[f]
+	case *ast.SelectorExpr:
+		if sel, ok := info.Selections[f]; ok {
+			obj = sel.Obj()
+		} else {
+			obj = info.Uses[f.Sel]
+		}
+	}
+
+	fn, _ := obj.(*types.Func)
+	return fn
+}
+
+// concreteTypeOf returns the type a value assigned to a variable of interface type holds, looking through
+// explicit conversions (i.e. `Store(&memStore{})`). It returns nil if that type is itself an interface.
+func concreteTypeOf(info *types.Info, in ast.Expr) types.Type {
+	in = ast.Unparen(in)
+	if call, ok := in.(*ast.CallExpr); ok && len(call.Args) == 1 && info.Types[call.Fun].IsType() {
+		return concreteTypeOf(info, call.Args[0])
+	}
+
+	t := info.TypeOf(in)
+	if t == nil || types.IsInterface(t) {
+		return nil
+	}
+	return t
+}
+
+// findInterfaceImpls finds every variable of interface type in the given files, along with the
+// concrete types assigned to it, i.e. `memStore` for `var s Store = &memStore{}`.
+func findInterfaceImpls(info *types.Info, files []*ast.File) map[types.Object][]types.Type {
+	impls := map[types.Object][]types.Type{}
+	record := func(ident *ast.Ident, value ast.Expr) {
+		obj := info.ObjectOf(ident)
+		if obj == nil || !types.IsInterface(obj.Type()) {
+			return
+		}
+		if t := concreteTypeOf(info, value); t != nil {
+			impls[obj] = append(impls[obj], t)
+		}
+	}
+
+	for _, f := range files {
+		ast.Inspect(f, func(n ast.Node) bool {
+			switch x := n.(type) {
+			case *ast.AssignStmt:
+				if len(x.Lhs) == len(x.Rhs) {
+					for i := range x.Lhs {
+						if ident, ok := x.Lhs[i].(*ast.Ident); ok {
+							record(ident, x.Rhs[i])
+						}
+					}
+				}
+			case *ast.ValueSpec:
+				if len(x.Names) == len(x.Values) {
+					for i := range x.Names {
+						record(x.Names[i], x.Values[i])
+					}
+				}
+			}
+			return true
+		})
+	}
+	return impls
+}
+
+// interfaceCallees returns the methods of the package under analysis that a method call through a
+// variable of interface type dispatches to, given the concrete types the variable is assigned.
+func interfaceCallees(info *types.Info, impls map[types.Object][]types.Type, pkg *types.Package, in *ast.SelectorExpr) []*types.Func {
+	sel, ok := info.Selections[in]
+	if !ok || sel.Kind() != types.MethodVal || !types.IsInterface(sel.Recv()) {
+		return nil
+	}
+
+	x, ok := ast.Unparen(in.X).(*ast.Ident)
+	if !ok {
+		return nil
+	}
+
+	var out []*types.Func
+	for _, t := range impls[info.ObjectOf(x)] {
+		obj, _, _ := types.LookupFieldOrMethod(t, true, sel.Obj().Pkg(), sel.Obj().Name())
+		if fn, ok := obj.(*types.Func); ok && fn.Pkg() == pkg {
+			out = append(out, fn)
+		}
+	}
+	return out
+}
+
+// checkFiles type-checks a set of files as a single package, failing on the first error encountered.
+func (a *analyzer) checkFiles(path string, files []*ast.File, imp types.Importer, info *types.Info) (*types.Package, error) {
+	var firstErr error
+	conf := types.Config{
+		Importer: imp,
+		// cgo isn't run, so references to package C can't be checked.
+		FakeImportC: true,
+		Error: func(err error) {
+			if firstErr == nil {
+				firstErr = err
+			}
+		},
+	}
+
+	pkg, _ := conf.Check(path, a.fileset, files, info)
+	if firstErr != nil {
+		return nil, errors.Wrap(firstErr, "type checking package")
+	}
+	return pkg, nil
+}
+
+// typeCheck type checks the package (along with its in-package and external tests) and records
+// every function in the package under analysis that is called from a test entry point, or from
+// a function declared in the test files that one of them reaches.
+func (a *analyzer) typeCheck(astPkg map[string]*ast.Package) error {
+	var pkgFiles, xtestFiles, testFiles, prodFiles []*ast.File
+	filenames := []string{}
+	filesByName := map[string]*ast.File{}
+	xtestByName := map[string]bool{}
+	for _, pkg := range astPkg {
+		for name, f := range pkg.Files {
+			filenames = append(filenames, name)
+			filesByName[name] = f
+			xtestByName[name] = strings.HasSuffix(pkg.Name, "_test")
+		}
+	}
+	sort.Strings(filenames)
+
+	for _, name := range filenames {
+		f := filesByName[name]
+		if xtestByName[name] {
+			xtestFiles = append(xtestFiles, f)
+		} else {
+			pkgFiles = append(pkgFiles, f)
+		}
+		if strings.HasSuffix(name, "_test.go") {
+			testFiles = append(testFiles, f)
+		} else {
+			prodFiles = append(prodFiles, f)
+		}
+	}
+
+	info := &types.Info{
+		Defs:       map[*ast.Ident]types.Object{},
+		Uses:       map[*ast.Ident]types.Object{},
+		Types:      map[ast.Expr]types.TypeAndValue{},
+		Selections: map[*ast.SelectorExpr]*types.Selection{},
+	}
+	// imports are resolved from the package's directory, so the right module is used wherever blanket runs from.
+	ctxt := buildContext(a.options)
+	ctxt.Dir = a.packageDir
+	imp := &packageImporter{base: newSourceImporter(ctxt, a.fileset)}
+
+	pkg, err := a.checkFiles(a.importPath, pkgFiles, imp, info)
+	if err != nil {
+		return err
+	}
+
+	if len(xtestFiles) > 0 {
+		imp.pkg = pkg
+		if _, err = a.checkFiles(a.importPath+"_test", xtestFiles, imp, info); err != nil {
+			return err
+		}
+	}
+
+	var impls map[types.Object][]types.Type
+	if a.options.InterfaceCalls {
+		impls = findInterfaceImpls(info, testFiles)
+	}
+
+	testFuncs := map[types.Object]testFunc{}
+	testHelpers := map[types.Object]bool{}
+	var entryPoints []types.Object
+	for _, f := range testFiles {
+		for _, d := range f.Decls {
+			if n, ok := d.(*ast.FuncDecl); ok && n.Body != nil {
+				obj := info.Defs[n.Name]
+				testFuncs[obj] = testFunc{decl: n, file: f}
+				if isTestEntryPoint(n) {
+					entryPoints = append(entryPoints, obj)
+				} else if a.isTestHelper(testFuncs[obj]) {
+					testHelpers[obj] = true
+				}
+			}
+		}
+	}
+
+	type reachedObject struct {
+		obj       types.Object
+		depth     int
+		viaHelper bool
+		category  string
+	}
+	var reached []reachedObject
+	inspect := func(node ast.Node) {
+		ast.Inspect(node, func(n ast.Node) bool {
+			switch x := n.(type) {
+			case *ast.CallExpr:
+				if fn := calleeOf(info, x); fn != nil && fn.Pkg() == pkg {
+					a.markCalled(typedFuncName(fn))
+				}
+				if sel, ok := ast.Unparen(x.Fun).(*ast.SelectorExpr); ok && impls != nil {
+					for _, fn := range interfaceCallees(info, impls, pkg, sel) {
+						a.interfaceCalledFuncs.Add(typedFuncName(fn))
+					}
+				}
+			case *ast.Ident:
+				if obj := info.Uses[x]; testFuncs[obj].decl != nil {
+					// a function declared in the test files, whether it's called or passed around as a value.
+					reached = append(reached, reachedObject{
+						obj:       obj,
+						depth:     a.callDepth + 1,
+						viaHelper: a.viaHelper || testHelpers[obj],
+						category:  a.testCategory,
+					})
+				}
+				// any other reference to a function or method is a function value, i.e. `run(t, Parse)`
+				if fn, ok := info.Uses[x].(*types.Func); ok && fn.Pkg() == pkg && !a.options.IgnoreFuncValues {
+					a.markCalled(typedFuncName(fn))
+				}
+			}
+			return true
+		})
+	}
+
+	// follow parses the functions declared in the test files reached so far, breadth first.
+	follow := func() {
+		visited := map[types.Object]bool{}
+		for len(reached) > 0 {
+			r := reached[0]
+			reached = reached[1:]
+			if visited[r.obj] || isTestEntryPoint(testFuncs[r.obj].decl) {
+				continue
+			}
+			visited[r.obj] = true
+
+			a.testCategory = r.category
+			a.viaHelper = r.viaHelper
+			a.callDepth = r.depth
+			inspect(testFuncs[r.obj].decl.Body)
+		}
+		a.viaHelper = false
+		a.callDepth = 0
+	}
+
+	// package level values, like test tables, are treated as if they were part of a test body.
+	for _, f := range testFiles {
+		a.testCategory = buildCategory(f)
+		for _, d := range f.Decls {
+			if n, ok := d.(*ast.GenDecl); ok {
+				inspect(n)
+			}
+		}
+	}
+	follow()
+
+	// every test is followed on its own, so that the calls its helpers make are attributed to it.
+	for _, obj := range entryPoints {
+		a.currentTest = a.parseFuncDecl(testFuncs[obj].decl)
+		a.testCategory = buildCategory(testFuncs[obj].file)
+		inspect(testFuncs[obj].decl.Body)
+		follow()
+	}
+	a.currentTest = ""
+
+	// the call graph of the production code, so we know how far from a test everything else is.
+	for _, f := range prodFiles {
+		for _, d := range f.Decls {
+			n, ok := d.(*ast.FuncDecl)
+			if !ok || n.Body == nil {
+				continue
+			}
+
+			callees := set.New()
+			ast.Inspect(n.Body, func(node ast.Node) bool {
+				if x, ok := node.(*ast.Ident); ok {
+					if fn, ok := info.Uses[x].(*types.Func); ok && fn.Pkg() == pkg {
+						callees.Add(typedFuncName(fn))
+					}
+				}
+				return true
+			})
+			a.productionCalls[a.parseFuncDecl(n)] = callees
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,509 @@
+package analysis
+
+import (
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"testing"
+
+	"gitlab.com/verygoodsoftwarenotvirus/blanket/lib/util"
+
+	"github.com/fatih/set"
+	"github.com/stretchr/testify/assert"
+)
+
+////////////////////////////////////////////////////////
+//                                                    //
+//               Test Helper Functions                //
+//                                                    //
+////////////////////////////////////////////////////////
+
+// parseTypedChunkOfCode parses a chunk of code with the analyzer's file set, so that it can be type checked.
+func parseTypedChunkOfCode(t *testing.T, analyzer *analyzer, chunkOfCode string) *ast.File {
+	t.Helper()
+	p, err := parser.ParseFile(analyzer.fileset, "example.go", chunkOfCode, parser.AllErrors)
+	if err != nil {
+		t.Logf("failing because ParseFile returned error: %v", err)
+		t.FailNow()
+	}
+	return p
+}
+
+func typeCheckChunkOfCode(t *testing.T, analyzer *analyzer, chunkOfCode string) (*ast.File, *types.Info) {
+	t.Helper()
+	p := parseTypedChunkOfCode(t, analyzer, chunkOfCode)
+
+	info := &types.Info{
+		Defs:       map[*ast.Ident]types.Object{},
+		Uses:       map[*ast.Ident]types.Object{},
+		Types:      map[ast.Expr]types.TypeAndValue{},
+		Selections: map[*ast.SelectorExpr]*types.Selection{},
+	}
+	imp := &packageImporter{base: newSourceImporter(buildContext(Options{}), analyzer.fileset)}
+	if _, err := analyzer.checkFiles("example", []*ast.File{p}, imp, info); err != nil {
+		t.Logf("failing because checkFiles returned error: %v", err)
+		t.FailNow()
+	}
+	return p, info
+}
+
+// buildTypedExamplePackage returns a package declaring A and B, along with a test file made of a given chunk of code.
+func buildTypedExamplePackage(t *testing.T, analyzer *analyzer, testCode string) map[string]*ast.Package {
+	t.Helper()
+	analyzer.importPath = "example.com/example"
+	codeSample := `
+		package example
+
+		func A() {}
+		func B() {}
+	`
+	return map[string]*ast.Package{
+		"example": {
+			Name: "example",
+			Files: map[string]*ast.File{
+				"example.go":      parseTypedChunkOfCode(t, analyzer, codeSample),
+				"example_test.go": parseTypedChunkOfCode(t, analyzer, testCode),
+			},
+		},
+	}
+}
+
+// buildSourceImporter returns a source importer that resolves imports from a given directory.
+func buildSourceImporter(dir string, opts Options) *sourceImporter {
+	ctxt := buildContext(opts)
+	ctxt.Dir = dir
+	return newSourceImporter(ctxt, token.NewFileSet())
+}
+
+const interfaceExample = `
+	package example
+
+	type Store interface{ Get() int }
+
+	type memStore struct{}
+	func (m *memStore) Get() int { return 1 }
+
+	type otherStore struct{}
+	func (o otherStore) Get() int { return 2 }
+
+	func main() {
+		var s Store = &memStore{}
+		o := Store(otherStore{})
+		var u Store = s
+		m := &memStore{}
+		s.Get()
+		o.Get()
+		u.Get()
+		m.Get()
+	}
+`
+
+////////////////////////////////////////////////////////
+//                                                    //
+//                   Actual Tests                     //
+//                                                    //
+////////////////////////////////////////////////////////
+
+func TestTypedFuncName(t *testing.T) {
+	analyzer := NewAnalyzer()
+	codeSample := `
+		package main
+
+		type Example struct{}
+		func (e Example) value() {}
+		func (e *Example) pointer() {}
+		func function() {}
+
+		func main() {
+			function()
+			Example{}.value()
+			(&Example{}).pointer()
+		}
+	`
+
+	p, info := typeCheckChunkOfCode(t, analyzer, codeSample)
+	body := p.Decls[len(p.Decls)-1].(*ast.FuncDecl).Body.List
+
+	expected := []string{"function", "Example.value", "Example.pointer"}
+	for i, stmt := range body {
+		fn := calleeOf(info, stmt.(*ast.ExprStmt).X.(*ast.CallExpr))
+		assert.NotNil(t, fn, "expected call to be resolved")
+		assert.Equal(t, expected[i], typedFuncName(fn), "expected function name to match")
+	}
+}
+
+func TestCalleeOf(t *testing.T) {
+	analyzer := NewAnalyzer()
+	codeSample := `
+		package main
+
+		type Example struct{}
+		func (e Example) method() {}
+
+		func main() {
+			var f func()
+			f()
+			_ = int(1)
+			_ = len("builtin")
+			(Example{}).method()
+		}
+	`
+
+	p, info := typeCheckChunkOfCode(t, analyzer, codeSample)
+	body := p.Decls[len(p.Decls)-1].(*ast.FuncDecl).Body.List
+
+	t.Run("function value", func(_t *testing.T) {
+		assert.Nil(t, calleeOf(info, body[1].(*ast.ExprStmt).X.(*ast.CallExpr)))
+	})
+
+	t.Run("type conversion", func(_t *testing.T) {
+		assert.Nil(t, calleeOf(info, body[2].(*ast.AssignStmt).Rhs[0].(*ast.CallExpr)))
+	})
+
+	t.Run("builtin", func(_t *testing.T) {
+		assert.Nil(t, calleeOf(info, body[3].(*ast.AssignStmt).Rhs[0].(*ast.CallExpr)))
+	})
+
+	t.Run("parenthesized method", func(_t *testing.T) {
+		fn := calleeOf(info, body[4].(*ast.ExprStmt).X.(*ast.CallExpr))
+		assert.NotNil(t, fn)
+		assert.Equal(t, "method", fn.Name())
+	})
+}
+
+func TestSourceImporter(t *testing.T) {
+	t.Setenv("GO111MODULE", "on")
+	dir := t.TempDir()
+	util.WriteFiles(t, dir, map[string]string{
+		"go.mod":             "module example.com/tagged\n\ngo 1.21\n",
+		"dep/dep.go":         "package dep\n",
+		"dep/dep_special.go": "//go:build special\n\npackage dep\n\nfunc Special() int { return 1 }\n",
+		"broken/broken.go":   "package broken\n\nvar Broken int = \"nope\"\n",
+	})
+
+	t.Run("Import", func(_t *testing.T) {
+		imp := buildSourceImporter(dir, Options{})
+
+		actual, err := imp.Import("strings")
+
+		assert.NoError(t, err)
+		if assert.NotNil(t, actual) {
+			assert.Equal(t, "strings", actual.Path())
+			assert.True(t, actual.Complete())
+		}
+		again, err := imp.Import("strings")
+		assert.NoError(t, err)
+		assert.True(t, actual == again, "expected packages to be type checked once")
+	})
+
+	t.Run("ImportFrom with build tags", func(_t *testing.T) {
+		actual, err := buildSourceImporter(dir, Options{Tags: []string{"special"}}).ImportFrom("example.com/tagged/dep", dir, 0)
+
+		assert.NoError(t, err)
+		if assert.NotNil(t, actual) {
+			assert.NotNil(t, actual.Scope().Lookup("Special"))
+		}
+	})
+
+	t.Run("ImportFrom without build tags", func(_t *testing.T) {
+		actual, err := buildSourceImporter(dir, Options{}).ImportFrom("example.com/tagged/dep", dir, 0)
+
+		assert.NoError(t, err)
+		if assert.NotNil(t, actual) {
+			assert.Nil(t, actual.Scope().Lookup("Special"))
+		}
+	})
+
+	t.Run("ImportFrom with unsafe", func(_t *testing.T) {
+		actual, err := buildSourceImporter(dir, Options{}).ImportFrom("unsafe", dir, 0)
+
+		assert.NoError(t, err)
+		assert.True(t, types.Unsafe == actual)
+	})
+
+	t.Run("ImportFrom with type errors", func(_t *testing.T) {
+		_, err := buildSourceImporter(dir, Options{}).ImportFrom("example.com/tagged/broken", dir, 0)
+		assert.Error(t, err)
+	})
+
+	t.Run("ImportFrom with a nonexistent package", func(_t *testing.T) {
+		_, err := buildSourceImporter(dir, Options{}).ImportFrom("example.com/tagged/nonexistent", dir, 0)
+		assert.Error(t, err)
+	})
+}
+
+func TestPackageImporter(t *testing.T) {
+	analyzer := NewAnalyzer()
+	pkg := types.NewPackage("example.com/example", "example")
+	imp := &packageImporter{base: newSourceImporter(buildContext(Options{}), analyzer.fileset), pkg: pkg}
+
+	t.Run("Import", func(_t *testing.T) {
+		actual, err := imp.Import("example.com/example")
+
+		assert.NoError(t, err)
+		assert.True(t, pkg == actual, "expected the package under analysis to be returned as is")
+	})
+
+	t.Run("ImportFrom", func(_t *testing.T) {
+		actual, err := imp.ImportFrom("strings", "", 0)
+
+		assert.NoError(t, err)
+		if assert.NotNil(t, actual) {
+			assert.Equal(t, "strings", actual.Path())
+		}
+	})
+
+	t.Run("ImportFrom with a nonexistent package", func(_t *testing.T) {
+		_, err := imp.ImportFrom("example.com/nonexistent", "", 0)
+		assert.Error(t, err)
+	})
+}
+
+func TestCheckFiles(t *testing.T) {
+	t.Run("normal", func(_t *testing.T) {
+		analyzer := NewAnalyzer()
+		codeSample := `
+			package example
+
+			import "strings"
+
+			func upper(s string) string {
+				return strings.ToUpper(s)
+			}
+		`
+		files := []*ast.File{parseTypedChunkOfCode(t, analyzer, codeSample)}
+		info := &types.Info{Uses: map[*ast.Ident]types.Object{}}
+		imp := &packageImporter{base: newSourceImporter(buildContext(Options{}), analyzer.fileset)}
+
+		actual, err := analyzer.checkFiles("example.com/example", files, imp, info)
+
+		assert.NoError(t, err)
+		if assert.NotNil(t, actual) {
+			assert.Equal(t, "example.com/example", actual.Path())
+			assert.NotNil(t, actual.Scope().Lookup("upper"))
+		}
+		assert.NotEmpty(t, info.Uses, "expected checkFiles to fill in the provided info")
+	})
+
+	t.Run("with cgo", func(_t *testing.T) {
+		analyzer := NewAnalyzer()
+		codeSample := `
+			package example
+
+			// #include <stdlib.h>
+			import "C"
+
+			func random() int {
+				return int(C.rand())
+			}
+		`
+		files := []*ast.File{parseTypedChunkOfCode(t, analyzer, codeSample)}
+		imp := &packageImporter{base: newSourceImporter(buildContext(Options{}), analyzer.fileset)}
+
+		actual, err := analyzer.checkFiles("example.com/example", files, imp, &types.Info{})
+
+		assert.NoError(t, err)
+		assert.NotNil(t, actual)
+	})
+
+	t.Run("with type errors", func(_t *testing.T) {
+		analyzer := NewAnalyzer()
+		codeSample := `
+			package example
+
+			func broken() int {
+				return "nope"
+			}
+		`
+		files := []*ast.File{parseTypedChunkOfCode(t, analyzer, codeSample)}
+		imp := &packageImporter{base: newSourceImporter(buildContext(Options{}), analyzer.fileset)}
+
+		actual, err := analyzer.checkFiles("example.com/example", files, imp, &types.Info{})
+
+		assert.Error(t, err)
+		assert.Nil(t, actual)
+	})
+}
+
+func TestTypeCheck(t *testing.T) {
+	t.Run("normal", func(_t *testing.T) {
+		analyzer := NewAnalyzer()
+		testCode := `
+			package example
+
+			import "testing"
+
+			func TestA(t *testing.T) {
+				A()
+			}
+		`
+
+		err := analyzer.typeCheck(buildTypedExamplePackage(t, analyzer, testCode))
+
+		assert.NoError(t, err)
+		assert.Equal(t, set.New("init", "A"), analyzer.calledFuncs)
+	})
+
+	t.Run("with a test that doesn't compile", func(_t *testing.T) {
+		analyzer := NewAnalyzer()
+		testCode := `
+			package example
+
+			import "testing"
+
+			func TestA(t *testing.T) {
+				A(t)
+			}
+		`
+
+		err := analyzer.typeCheck(buildTypedExamplePackage(t, analyzer, testCode))
+		assert.Error(t, err)
+	})
+}
+
+func TestTypedConcreteTypeOf(t *testing.T) {
+	analyzer := NewAnalyzer()
+	p, info := typeCheckChunkOfCode(t, analyzer, interfaceExample)
+	body := p.Decls[len(p.Decls)-1].(*ast.FuncDecl).Body.List
+	valueOf := func(stmt ast.Stmt) ast.Expr {
+		if assign, ok := stmt.(*ast.AssignStmt); ok {
+			return assign.Rhs[0]
+		}
+		return stmt.(*ast.DeclStmt).Decl.(*ast.GenDecl).Specs[0].(*ast.ValueSpec).Values[0]
+	}
+
+	t.Run("concrete value", func(_t *testing.T) {
+		actual := concreteTypeOf(info, valueOf(body[0]))
+		if assert.NotNil(t, actual) {
+			assert.Equal(t, "*example.memStore", actual.String())
+		}
+	})
+
+	t.Run("conversion", func(_t *testing.T) {
+		actual := concreteTypeOf(info, valueOf(body[1]))
+		if assert.NotNil(t, actual) {
+			assert.Equal(t, "example.otherStore", actual.String())
+		}
+	})
+
+	t.Run("interface value", func(_t *testing.T) {
+		assert.Nil(t, concreteTypeOf(info, valueOf(body[2])))
+	})
+}
+
+func TestFindInterfaceImpls(t *testing.T) {
+	analyzer := NewAnalyzer()
+	p, info := typeCheckChunkOfCode(t, analyzer, interfaceExample)
+
+	actual := map[string][]string{}
+	for obj, impls := range findInterfaceImpls(info, []*ast.File{p}) {
+		for _, impl := range impls {
+			actual[obj.Name()] = append(actual[obj.Name()], impl.String())
+		}
+	}
+
+	expected := map[string][]string{"s": {"*example.memStore"}, "o": {"example.otherStore"}}
+	assert.Equal(t, expected, actual)
+}
+
+func TestInterfaceCallees(t *testing.T) {
+	analyzer := NewAnalyzer()
+	p, info := typeCheckChunkOfCode(t, analyzer, interfaceExample)
+	main := p.Decls[len(p.Decls)-1].(*ast.FuncDecl)
+	pkg := info.Defs[main.Name].Pkg()
+	impls := findInterfaceImpls(info, []*ast.File{p})
+	selectorOf := func(i int) *ast.SelectorExpr {
+		return main.Body.List[i].(*ast.ExprStmt).X.(*ast.CallExpr).Fun.(*ast.SelectorExpr)
+	}
+
+	t.Run("with a known implementation", func(_t *testing.T) {
+		actual := interfaceCallees(info, impls, pkg, selectorOf(4))
+		if assert.Len(t, actual, 1) {
+			assert.Equal(t, "memStore.Get", typedFuncName(actual[0]))
+		}
+	})
+
+	t.Run("without a known implementation", func(_t *testing.T) {
+		assert.Empty(t, interfaceCallees(info, impls, pkg, selectorOf(6)))
+	})
+
+	t.Run("concrete receiver", func(_t *testing.T) {
+		assert.Empty(t, interfaceCallees(info, impls, pkg, selectorOf(7)))
+	})
+
+	t.Run("another package", func(_t *testing.T) {
+		assert.Empty(t, interfaceCallees(info, impls, types.NewPackage("example.com/other", "other"), selectorOf(5)))
+	})
+}
+
+func TestAnalyzeWithTypeCheck(t *testing.T) {
+	analyzer := NewAnalyzer()
+	analyzer.SetOptions(Options{TypeCheck: true})
+
+	examplePath := util.BuildExamplePackagePath(t, "typed", false)
+	actual, err := analyzer.Analyze(examplePath)
+
+	assert.NoError(t, err, "Analyze produced an unexpected error")
+	assert.Equal(t, set.New("newStore", "store.get", "store.set", "service.lookup", "stores"), actual.Called)
+	assert.Equal(t, set.New("newStore", "store.get", "store.set", "service.lookup", "stores", "untested"), actual.Declared)
+}
+
+func TestBuildContext(t *testing.T) {
+	t.Run("default", func(_t *testing.T) {
+		actual := buildContext(Options{})
+
+		assert.Equal(t, build.Default.GOOS, actual.GOOS)
+		assert.Equal(t, build.Default.CgoEnabled, actual.CgoEnabled)
+		assert.Empty(t, actual.BuildTags)
+	})
+
+	t.Run("with tags and another platform", func(_t *testing.T) {
+		platform := Platform{GOOS: "plan9", GOARCH: "arm"}
+		actual := buildContext(Options{Tags: []string{"integration"}, Platform: platform})
+
+		assert.Equal(t, []string{"integration"}, actual.BuildTags)
+		assert.Equal(t, "plan9", actual.GOOS)
+		assert.Equal(t, "arm", actual.GOARCH)
+		assert.False(t, actual.CgoEnabled)
+		assert.Empty(t, build.Default.BuildTags, "buildContext shouldn't modify build.Default")
+	})
+}
+
+func TestTypeCheckWithBuildContext(t *testing.T) {
+	t.Setenv("GO111MODULE", "on")
+	dir := t.TempDir()
+	// the package's dependency only declares what it uses with the same tags and platform.
+	util.WriteFiles(t, dir, map[string]string{
+		"go.mod":                 "module example.com/tagged\n\ngo 1.21\n",
+		"doc.go":                 "package tagged\n",
+		"special.go":             "//go:build special\n\npackage tagged\n\nimport \"example.com/tagged/dep\"\n\nfunc A() int {\n\treturn dep.Special()\n}\n",
+		"special_test.go":        "//go:build special\n\npackage tagged\n\nimport \"testing\"\n\nfunc TestA(t *testing.T) {\n\tA()\n}\n",
+		"tagged_windows.go":      "package tagged\n\nimport \"example.com/tagged/dep\"\n\nfunc B() int {\n\treturn dep.Windows()\n}\n",
+		"tagged_windows_test.go": "package tagged\n\nimport \"testing\"\n\nfunc TestB(t *testing.T) {\n\tB()\n}\n",
+		"dep/dep.go":             "package dep\n",
+		"dep/dep_special.go":     "//go:build special\n\npackage dep\n\nfunc Special() int { return 1 }\n",
+		"dep/dep_windows.go":     "package dep\n\nfunc Windows() int { return 2 }\n",
+	})
+
+	t.Run("with tags", func(_t *testing.T) {
+		actual, err := analyzePackagesIn(dir, Options{TypeCheck: true, Tags: []string{"special"}, Platform: Platform{GOOS: "linux", GOARCH: "amd64"}}, ".")
+
+		assert.NoError(t, err)
+		if assert.NotNil(t, actual) {
+			assert.Equal(t, 1, actual.DeclaredCount)
+			assert.Equal(t, 1, actual.CalledCount)
+		}
+	})
+
+	t.Run("with another platform", func(_t *testing.T) {
+		actual, err := analyzePackagesIn(dir, Options{TypeCheck: true, Platform: Platform{GOOS: "windows", GOARCH: "amd64"}}, ".")
+
+		assert.NoError(t, err)
+		if assert.NotNil(t, actual) {
+			assert.Equal(t, 1, actual.DeclaredCount)
+			assert.Equal(t, 1, actual.CalledCount)
+		}
+	})
+}
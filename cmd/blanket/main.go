@@ -9,28 +9,79 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
 	"text/template"
 	"unicode/utf8"
 
 	"gitlab.com/verygoodsoftwarenotvirus/blanket/analysis"
+	"gitlab.com/verygoodsoftwarenotvirus/blanket/config"
+	"gitlab.com/verygoodsoftwarenotvirus/blanket/lib/git"
 	"gitlab.com/verygoodsoftwarenotvirus/blanket/output/html"
+	"gitlab.com/verygoodsoftwarenotvirus/blanket/output/sarif"
 
 	"github.com/fatih/color"
-	"github.com/fatih/set"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"golang.org/x/tools/cover"
 )
 
 const (
+	viaInterfaceTmpl = `{{if .ViaInterface}}{{$len := .LongestFunctionNameLength}}Functions only called through interfaces:{{range $filename, $funcs := .ViaInterface}}
+in {{colorizer $filename "white" true}}:{{range $funcs}}
+	{{pad .Name $len}} on line {{.DeclPos.Line}}{{end}}{{end}}
+
+{{end}}`
+	viaHelperTmpl = `{{if .ViaHelper}}{{$len := .LongestFunctionNameLength}}Functions only called through test helpers:{{range $filename, $funcs := .ViaHelper}}
+in {{colorizer $filename "white" true}}:{{range $funcs}}
+	{{pad .Name $len}} on line {{.DeclPos.Line}}{{with index $.Depths .Name}} (depth {{.}}){{end}}{{end}}{{end}}
+
+{{end}}`
+	suppressedTmpl = `{{if .Suppressed}}Suppressed functions:{{range .Suppressed}}
+	{{.Name}} in {{colorizer .Filename "white" true}} on line {{.Line}}{{with .Reason}}: {{.}}{{end}}{{with .Until}} (until {{.}}){{end}}{{end}}
+
+{{end}}`
+	generatedTmpl = `{{with .GeneratedCount}}Skipped {{.}} functions in generated files.
+
+{{end}}`
+	baselineTmpl = `{{if .New}}Functions without direct unit tests missing from the baseline:{{range $pkg, $names := .New}}
+in {{colorizer $pkg "white" true}}:{{range $names}}
+	{{.}}{{end}}{{end}}
+
+{{end}}{{if .Fixed}}Baseline entries that aren't untested anymore (remove them with --tighten-baseline):{{range $pkg, $names := .Fixed}}
+in {{colorizer $pkg "white" true}}:{{range $names}}
+	{{.}}{{end}}{{end}}
+
+{{end}}`
+	compareTmpl = `{{define "group"}}{{range $dir, $names := .}}
+in {{colorizer $dir "white" true}}:{{range $names}}
+	{{.}}{{end}}{{end}}
+
+{{end}}{{with .NewlyUntested}}Functions that lost their direct unit tests:{{template "group" .}}{{end}}{{with .AddedUntested}}New functions without direct unit tests:{{template "group" .}}{{end}}{{with .NewlyTested}}Functions with new direct unit tests:{{template "group" .}}{{end}}{{with .Removed}}Removed functions:{{template "group" .}}{{end}}Grade: {{grader .BaseScore}} at {{.Base}}, {{grader .HeadScore}} at {{.Head}} ({{printf "%+d" .ScoreChange}})`
+	diffTmpl = `{{range .Packages}}{{if .Changed}}{{colorizer .Package "cyan" true}}: {{if .Added}}new package{{else}}{{grader .OldScore}}{{end}} -> {{if .Removed}}removed{{else}}{{grader .NewScore}}{{end}}{{range .AddedUntested}}
+	newly untested: {{.}}{{end}}{{range .RemovedUntested}}
+	no longer untested: {{.}}{{end}}
+
+{{end}}{{end}}Total grade: {{grader .OldScore}} -> {{grader .NewScore}} ({{printf "%+d" .ScoreChange}})`
+	diffMarkdownTmpl = `| Package | Old grade | New grade | Newly untested | No longer untested |
+| --- | --- | --- | --- | --- |
+{{range .Packages}}{{if .Changed}}| ` + "`{{.Package}}`" + ` | {{if .Added}}-{{else}}{{.OldScore}}%{{end}} | {{if .Removed}}-{{else}}{{.NewScore}}%{{end}} | {{range $i, $name := .AddedUntested}}{{if $i}}, {{end}}` + "`{{$name}}`" + `{{end}} | {{range $i, $name := .RemovedUntested}}{{if $i}}, {{end}}` + "`{{$name}}`" + `{{end}} |
+{{end}}{{end}}
+**Total grade:** {{.OldScore}}% -> {{.NewScore}}% ({{printf "%+d" .ScoreChange}})`
+	categoriesTmpl = `{{if gt (len .Categories) 1}}Direct calls by build constraint:{{range $category, $c := .Categories}}
+	{{$category}}: {{grader $c.Score}} ({{$c.CalledCount}}/{{$.DeclaredCount}} functions){{if $c.Only}}, only tested here: {{join $c.Only ", "}}{{end}}{{end}}
+
+{{end}}`
 	differenceReportTmpl = `{{$len := .LongestFunctionNameLength}}Functions without direct unit tests:{{range $filename, $missing := .Details}}
 in {{colorizer $filename "white" true}}:{{range $missing}}
-	{{pad .Name $len}} on line {{.DeclPos.Line}}{{end}}{{end}}
+	{{pad .Name $len}} on line {{.DeclPos.Line}}{{with index $.Depths .Name}} (depth {{.}}){{end}}{{with index $.UntestedOn .Name}} (untested on {{join . ", "}}){{end}}{{end}}{{end}}
 
-Grade: {{grader .Score}} ({{.CalledCount}}/{{.DeclaredCount}} functions)
+` + viaHelperTmpl + viaInterfaceTmpl + suppressedTmpl + generatedTmpl + categoriesTmpl + `Grade: {{grader .Score}} ({{.CalledCount}}/{{.DeclaredCount}} {{with .Scope}}{{.}} {{end}}functions)
 `
-	perfectScoreTmpl = `Grade: {{grader .Score}} ({{.CalledCount}}/{{.DeclaredCount}} functions)`
+	perfectScoreTmpl  = viaHelperTmpl + viaInterfaceTmpl + suppressedTmpl + generatedTmpl + categoriesTmpl + `Grade: {{grader .Score}} ({{.CalledCount}}/{{.DeclaredCount}} {{with .Scope}}{{.}} {{end}}functions)`
+	packageHeaderTmpl = `{{colorizer .Package "cyan" true}}`
+	totalScoreTmpl    = `Total grade: {{grader .Score}} ({{.CalledCount}}/{{.DeclaredCount}} {{with .Scope}}{{.}} {{end}}functions in {{len .Packages}} packages{{if .Platforms}} on {{join .Platforms ", "}}{{end}}{{with .GeneratedCount}}, skipped {{.}} generated{{end}})`
 )
 
 var (
@@ -41,7 +92,36 @@ var (
 	// analyze flags
 	failOnFound    bool
 	outputAsJSON   bool
+	outputFormat   string
+	typeCheck      bool
+	ignoreFuncVals bool
+	ifaceCalls     bool
+	countIface     bool
+	helperPatterns []string
+	indirectHelper bool
+	inclGenerated  bool
+	scope          string
+	buildTags      []string
+	goos           string
+	goarch         string
+	matrix         []string
+	minGrades      []string
 	analyzePackage string
+	baselinePath   string
+	tightenBase    bool
+	since          string
+	staged         bool
+
+	// baseline flags
+	baselineFile string
+
+	// diff flags
+	failOn         []string
+	scoreTolerance int
+
+	// compare flags
+	compareBase string
+	compareHead string
 
 	// cover flags
 	coverprofile string
@@ -68,6 +148,7 @@ var (
 	}
 
 	templateFuncMap = template.FuncMap{
+		"join": strings.Join,
 		"pad": func(s string, longest int) string {
 			return fmt.Sprintf("%s%s", strings.Repeat(" ", longest-utf8.RuneCountInString(s)), s)
 		},
@@ -100,37 +181,262 @@ var (
 		Short: "Analyze a given package",
 		Long:  "Analyze takes a given package and determines which functions lack direct unit tests.",
 		Run: func(cmd *cobra.Command, args []string) {
-			// TODO: figure out why tests won't capture coverage for this
-			// if len(args) == 1 && analyzePackage == "." {
-			// 		analyzePackage = args[0]
-			// }
-			analyzer := analysis.NewAnalyzer()
-			report, err := analyzer.Analyze(analyzePackage)
+			patterns := args
+			if len(patterns) == 0 {
+				patterns = []string{analyzePackage}
+			}
+
+			cfg, err := loadConfig()
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			opts, err := analyzeOptions(cfg)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			format, err := reportFormat(cfg, "text", "json", "sarif")
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			minimums, err := parseCategoryMinimums(minGrades)
 			if err != nil {
 				log.Fatal(err)
 			}
 
-			diff := set.StringSlice(set.Difference(report.Declared, report.Called))
-			diffReport := analyzer.GenerateDiffReport()
+			var baseline *analysis.Baseline
+			if baselinePath != "" {
+				if baseline, err = analysis.LoadBaseline(baselinePath); err != nil {
+					log.Fatal(err)
+				}
+			}
+
+			summary, err := analysis.AnalyzePackages(opts, patterns...)
+			if err != nil {
+				log.Fatal(err)
+			}
 
-			if outputAsJSON {
-				json.NewEncoder(os.Stdout).Encode(diffReport)
+			if format == "json" {
+				json.NewEncoder(os.Stdout).Encode(summary.JSONReport())
+			} else if format == "sarif" {
+				untested := map[string][]analysis.BlanketFunc{}
+				for _, pkg := range summary.Packages {
+					for _, funcs := range pkg.Details {
+						untested[pkg.Package] = append(untested[pkg.Package], funcs...)
+					}
+				}
+				if err := sarif.Output(os.Stdout, sarifRoot(), untested); err != nil {
+					log.Fatal(err)
+				}
+			} else if len(summary.Packages) == 1 {
+				fmt.Println(renderReport(summary.Packages[0], summary.Missing()))
 			} else {
-				var templateToUse string
-				if len(diff) > 0 {
-					templateToUse = differenceReportTmpl
+				for _, pkg := range summary.Packages {
+					fmt.Println(renderTemplate(packageHeaderTmpl, pkg))
+					fmt.Println(strings.TrimRight(renderReport(pkg, len(pkg.Details) > 0), "\n"))
+					fmt.Println()
+				}
+				fmt.Println(renderTemplate(totalScoreTmpl, summary))
+			}
+
+			if baseline != nil {
+				comparison := summary.CompareToBaseline(baseline)
+				fmt.Fprint(os.Stderr, renderTemplate(baselineTmpl, comparison))
+				if tightenBase && len(comparison.Fixed) > 0 {
+					if err := baseline.Tighten(comparison).Write(baselinePath); err != nil {
+						log.Fatal(err)
+					}
+				}
+				if comparison.Regressed() {
+					os.Exit(1)
+				}
+			} else if summary.Missing() && failOnFound {
+				os.Exit(1)
+			}
+
+			if below := summary.CategoriesBelow(minimums); len(below) > 0 {
+				for _, category := range below {
+					fmt.Fprintf(os.Stderr, "grade for %s tests is below the minimum of %d%%\n", category, minimums[category])
+				}
+				os.Exit(1)
+			}
+
+			if summary.Score < cfg.MinGrade {
+				fmt.Fprintf(os.Stderr, "total grade is below the minimum of %d%%\n", cfg.MinGrade)
+				os.Exit(1)
+			}
+
+			if below := summary.DirectoriesBelow(cfg.Root, cfg.Directories); len(below) > 0 {
+				for _, dir := range below {
+					fmt.Fprintf(os.Stderr, "grade for %s is below the minimum of %d%%\n", dir, cfg.Directories[dir])
+				}
+				os.Exit(1)
+			}
+		},
+	}
+
+	configCmd = &cobra.Command{
+		Use:   "config",
+		Short: "Work with the configuration file",
+		Long:  "Config holds the commands for working with the " + config.Filename + " file at the root of a project.",
+	}
+
+	validateCmd = &cobra.Command{
+		Use:   "validate [file]",
+		Short: "Validate a configuration file",
+		Long:  "Validate reports unknown keys and invalid patterns in a configuration file. Defaults to the one for the current directory.",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			configPath, err := configPathFor(args)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			if _, err := config.Load(configPath); err != nil {
+				if v, ok := err.(*config.ValidationError); ok {
+					for _, problem := range v.Problems {
+						fmt.Fprintf(os.Stderr, "%s: %s\n", configPath, problem)
+					}
 				} else {
-					templateToUse = perfectScoreTmpl
+					fmt.Fprintln(os.Stderr, err)
 				}
+				os.Exit(1)
+				return
+			}
+			fmt.Printf("%s is valid\n", configPath)
+		},
+	}
+
+	baselineCmd = &cobra.Command{
+		Use:   "baseline",
+		Short: "Work with baseline files",
+		Long:  "Baseline holds the commands for working with the baseline files that analyze's --baseline flag compares runs to.",
+	}
+
+	baselineWriteCmd = &cobra.Command{
+		Use:   "write [packages]",
+		Short: "Record the functions that lack direct unit tests",
+		Long:  "Write analyzes the given packages the way analyze does, and records every function without direct unit tests in a baseline file.",
+		Run: func(cmd *cobra.Command, args []string) {
+			patterns := args
+			if len(patterns) == 0 {
+				patterns = []string{analyzePackage}
+			}
+
+			cfg, err := loadConfig()
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			opts, err := analyzeOptions(cfg)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			summary, err := analysis.AnalyzePackages(opts, patterns...)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			if err := summary.Baseline().Write(baselineFile); err != nil {
+				log.Fatal(err)
+			}
+			fmt.Printf("recorded %d functions without direct unit tests in %s\n", summary.DeclaredCount-summary.CalledCount, baselineFile)
+		},
+	}
+
+	compareCmd = &cobra.Command{
+		Use:   "compare [packages]",
+		Short: "Compare two revisions of a repository",
+		Long:  "Compare checks two revisions of the current repository out into temporary worktrees, analyzes both, and reports which functions gained or lost their direct unit tests.",
+		Run: func(cmd *cobra.Command, args []string) {
+			patterns := args
+			if len(patterns) == 0 {
+				patterns = []string{analyzePackage}
+			}
+			if compareBase == "" {
+				log.Fatal("--base is required")
+			}
 
-				var tpl bytes.Buffer
-				// see above re: the error this function returns
-				t, _ := template.New("t").Funcs(templateFuncMap).Parse(templateToUse)
-				t.Execute(&tpl, diffReport)
-				fmt.Println(tpl.String())
+			cfg, err := loadConfig()
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			opts, err := analyzeOptions(cfg)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			format, err := reportFormat(cfg, "text", "json")
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			wd, err := os.Getwd()
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			comparison, err := analysis.CompareRevisions(wd, compareBase, compareHead, opts, patterns...)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			if format == "json" {
+				json.NewEncoder(os.Stdout).Encode(comparison)
+			} else {
+				fmt.Println(renderTemplate(compareTmpl, comparison))
+			}
+		},
+	}
+
+	diffCmd = &cobra.Command{
+		Use:   "diff old.json new.json",
+		Short: "Compare two saved JSON reports",
+		Long:  "Diff compares two reports saved from analyze's JSON output package by package, and function by function, without needing the source code they came from.",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg, err := loadConfig()
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			format, err := reportFormat(cfg, "text", "json", "markdown")
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			rule, err := analysis.ParseRegressionRule(failOn, scoreTolerance)
+			if err != nil {
+				log.Fatal(err)
 			}
 
-			if len(diff) > 0 && failOnFound {
+			oldReport, err := analysis.LoadReport(args[0])
+			if err != nil {
+				log.Fatal(err)
+			}
+			newReport, err := analysis.LoadReport(args[1])
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			diff := analysis.DiffReports(oldReport, newReport)
+			switch format {
+			case "json":
+				json.NewEncoder(os.Stdout).Encode(diff)
+			case "markdown":
+				fmt.Println(renderTemplate(diffMarkdownTmpl, diff))
+			default:
+				fmt.Println(renderTemplate(diffTmpl, diff))
+			}
+
+			if regressions := diff.Regressions(rule); len(regressions) > 0 {
+				for _, regression := range regressions {
+					fmt.Fprintln(os.Stderr, regression)
+				}
 				os.Exit(1)
 			}
 		},
@@ -166,13 +472,219 @@ func init() {
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "verbose output")
 	fileset = token.NewFileSet()
 
-	analyzeCmd.Flags().BoolVarP(&outputAsJSON, "json", "j", false, "Render results as a JSON blob")
+	analyzeCmd.Flags().BoolVarP(&outputAsJSON, "json", "j", false, "Render results as a JSON blob (same as --format=json)")
+	analyzeCmd.Flags().StringVar(&outputFormat, "format", "", "Output format, either text, json or sarif. Defaults to the configuration file's, or text.")
 	analyzeCmd.Flags().BoolVarP(&failOnFound, "fail-on-found", "F", false, "Call os.Exit(1) when functions without direct tests are found")
-	analyzeCmd.Flags().StringVarP(&analyzePackage, "package", "p", ".", "Package to run analyze on. Defaults to the current directory.")
+	analyzeCmd.Flags().StringSliceVar(&minGrades, "min-category-grade", nil, "Comma separated list of category=grade pairs (i.e. unit=80,integration=50). Exits with 1 if the grade of any listed category of tests is lower.")
+	analyzeCmd.Flags().StringVar(&baselinePath, "baseline", "", "Baseline file written by `blanket baseline write`. Exits with 1 only if functions without direct tests are missing from it.")
+	analyzeCmd.Flags().StringVar(&since, "since", "", "Only analyze the functions changed since a given git revision, including uncommitted changes")
+	analyzeCmd.Flags().BoolVar(&staged, "staged", false, "Only analyze the functions with changes staged for commit")
+	analyzeCmd.Flags().BoolVar(&tightenBase, "tighten-baseline", false, "Remove the entries that are tested now from the --baseline file")
+	addAnalysisFlags(analyzeCmd.Flags())
 	rootCmd.AddCommand(analyzeCmd)
 
+	baselineWriteCmd.Flags().StringVarP(&baselineFile, "file", "f", analysis.BaselineFilename, "File to write the baseline to")
+	addAnalysisFlags(baselineWriteCmd.Flags())
+	baselineCmd.AddCommand(baselineWriteCmd)
+	rootCmd.AddCommand(baselineCmd)
+
+	compareCmd.Flags().StringVar(&compareBase, "base", "", "Revision to compare against, i.e. main")
+	compareCmd.Flags().StringVar(&compareHead, "head", "HEAD", "Revision to compare. Uncommitted changes aren't included.")
+	compareCmd.Flags().StringVar(&outputFormat, "format", "", "Output format, either text or json. Defaults to the configuration file's, or text.")
+	addAnalysisFlags(compareCmd.Flags())
+	rootCmd.AddCommand(compareCmd)
+
+	diffCmd.Flags().StringVar(&outputFormat, "format", "", "Output format, either text, json or markdown. Defaults to the configuration file's, or text.")
+	diffCmd.Flags().StringSliceVar(&failOn, "fail-on", []string{"untested"}, "Comma separated list of regressions to exit with 1 on: untested (new functions without direct tests), score (a lower total grade), package-score (a lower grade for any package), or none")
+	diffCmd.Flags().IntVar(&scoreTolerance, "score-tolerance", 0, "Percentage points a grade can drop by before it counts as a regression")
+	rootCmd.AddCommand(diffCmd)
+
 	coverCmd.Flags().StringVarP(&coverprofile, "html", "c", "", "coverprofile to generate HTML for.")
 	rootCmd.AddCommand(coverCmd)
+
+	configCmd.AddCommand(validateCmd)
+	rootCmd.AddCommand(configCmd)
+}
+
+// addAnalysisFlags adds the flags that decide what gets analyzed, and how, to a command.
+func addAnalysisFlags(flags *pflag.FlagSet) {
+	flags.BoolVarP(&typeCheck, "type-check", "t", false, "Resolve calls in tests using full type information")
+	flags.BoolVar(&ignoreFuncVals, "ignore-func-values", false, "Don't count functions passed as values (i.e. in test tables) as directly tested")
+	flags.BoolVar(&ifaceCalls, "interface-calls", false, "Report implementations reached through interface method calls in tests")
+	flags.BoolVar(&countIface, "count-interface-calls", false, "Count implementations reached through interface method calls as directly tested (implies --interface-calls)")
+	flags.StringSliceVar(&helperPatterns, "helper-pattern", nil, "Regular expression matching the names of additional functions in test files to treat as test helpers. Can be repeated.")
+	flags.BoolVar(&indirectHelper, "indirect-helper-calls", false, "Don't count functions that tests only call through test helpers as directly tested")
+	flags.StringVar(&scope, "scope", "", "Which functions to analyze: exported, unexported or all. Methods are only exported if their receiver type is too. Defaults to the configuration file's, or all.")
+	flags.BoolVar(&inclGenerated, "include-generated", false, "Analyze functions declared in generated files (the ones with a \"Code generated ... DO NOT EDIT.\" comment) instead of skipping them")
+	flags.StringSliceVar(&buildTags, "tags", nil, "Comma separated list of build tags to consider satisfied, like go build's -tags flag")
+	flags.StringVar(&goos, "goos", "", "Operating system to analyze packages for. Defaults to the go command's GOOS.")
+	flags.StringVar(&goarch, "goarch", "", "Architecture to analyze packages for. Defaults to the go command's GOARCH.")
+	flags.StringSliceVar(&matrix, "matrix", nil, "Comma separated list of goos/goarch pairs to analyze. Functions count as untested if they lack a direct test on any of them.")
+	flags.StringVarP(&analyzePackage, "package", "p", ".", "Package to run analyze on. Defaults to the current directory.")
+}
+
+// configPathFor returns the configuration file named in a command's arguments, or the one for the current directory.
+func configPathFor(args []string) (string, error) {
+	if len(args) > 0 {
+		return args[0], nil
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	configPath, err := config.Find(wd)
+	if err != nil {
+		return "", err
+	}
+	if configPath == "" {
+		return "", fmt.Errorf("no %s found for %s", config.Filename, wd)
+	}
+	return configPath, nil
+}
+
+// loadConfig loads the configuration file for the current directory, if there is one.
+func loadConfig() (*config.Config, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+
+	configPath, err := config.Find(wd)
+	if err != nil || configPath == "" {
+		return &config.Config{}, err
+	}
+	return config.Load(configPath)
+}
+
+// sarifRoot returns the directory the locations in SARIF output are relative to: the root of the git repository
+// the working directory is in, since that's what code scanning dashboards resolve them against, or the working
+// directory itself outside of one.
+func sarifRoot() string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	if root, err := git.Root(wd); err == nil {
+		return root
+	}
+	return wd
+}
+
+// reportFormat decides which of the given formats to render results in, defaulting to text. Flags take
+// precedence over the configuration file, whose format only applies to the commands that support it.
+func reportFormat(cfg *config.Config, formats ...string) (string, error) {
+	format := "text"
+	for _, f := range formats {
+		if cfg.Format == f {
+			format = cfg.Format
+		}
+	}
+	if outputFormat != "" {
+		format = outputFormat
+	}
+	if outputAsJSON {
+		format = "json"
+	}
+
+	for _, f := range formats {
+		if format == f {
+			return format, nil
+		}
+	}
+	return "", fmt.Errorf("unknown format %q, expected one of %s", format, strings.Join(formats, ", "))
+}
+
+// analyzeOptions builds the analysis options from the configuration file and the analyze command's flags.
+// Flags can turn settings on, or add to the patterns, but never turn off what the configuration file sets.
+func analyzeOptions(cfg *config.Config) (analysis.Options, error) {
+	opts := cfg.Options()
+	opts.TypeCheck = opts.TypeCheck || typeCheck
+	opts.IgnoreFuncValues = opts.IgnoreFuncValues || ignoreFuncVals
+	opts.InterfaceCalls = opts.InterfaceCalls || ifaceCalls
+	opts.CountInterfaceCalls = opts.CountInterfaceCalls || countIface
+	opts.IndirectHelperCalls = opts.IndirectHelperCalls || indirectHelper
+	opts.IncludeGenerated = opts.IncludeGenerated || inclGenerated
+	if scope != "" {
+		s, err := analysis.ParseScope(scope)
+		if err != nil {
+			return opts, err
+		}
+		opts.Scope = s
+	}
+	opts.Tags = buildTags
+	opts.Platform = analysis.Platform{GOOS: goos, GOARCH: goarch}
+
+	for _, p := range helperPatterns {
+		pattern, err := regexp.Compile(p)
+		if err != nil {
+			return opts, fmt.Errorf("invalid helper pattern %q: %v", p, err)
+		}
+		opts.HelperPatterns = append(opts.HelperPatterns, pattern)
+	}
+
+	if since != "" && staged {
+		return opts, fmt.Errorf("--since and --staged can't be used together")
+	}
+	if since != "" || staged {
+		wd, err := os.Getwd()
+		if err != nil {
+			return opts, err
+		}
+
+		if staged {
+			opts.Changes, err = git.Staged(wd)
+		} else {
+			opts.Changes, err = git.ChangedSince(wd, since)
+		}
+		if err != nil {
+			return opts, err
+		}
+	}
+
+	for _, p := range matrix {
+		platform, err := analysis.ParsePlatform(p)
+		if err != nil {
+			return opts, err
+		}
+		opts.Matrix = append(opts.Matrix, platform)
+	}
+	return opts, nil
+}
+
+// parseCategoryMinimums parses the minimum grades passed to --min-category-grade.
+func parseCategoryMinimums(in []string) (map[string]int, error) {
+	minimums := map[string]int{}
+	for _, pair := range in {
+		i := strings.LastIndex(pair, "=")
+		if i <= 0 {
+			return nil, fmt.Errorf("invalid minimum grade %q, expected category=grade", pair)
+		}
+
+		grade, err := strconv.Atoi(pair[i+1:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid minimum grade %q, expected category=grade", pair)
+		}
+		minimums[pair[:i]] = grade
+	}
+	return minimums, nil
+}
+
+// renderTemplate executes one of the report templates above against the provided data.
+func renderTemplate(templateToUse string, data interface{}) string {
+	var tpl bytes.Buffer
+	// the templates are constants, so the only error Parse could return is a programming mistake.
+	t, _ := template.New("t").Funcs(templateFuncMap).Parse(templateToUse)
+	t.Execute(&tpl, data)
+	return tpl.String()
+}
+
+// renderReport renders the report for a single package, listing the functions without direct unit tests if there are any.
+func renderReport(data interface{}, missing bool) string {
+	if missing {
+		return renderTemplate(differenceReportTmpl, data)
+	}
+	return renderTemplate(perfectScoreTmpl, data)
 }
 
 func main() {
@@ -6,13 +6,16 @@ import (
 	"io/ioutil"
 	"log"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"gitlab.com/verygoodsoftwarenotvirus/blanket/analysis"
+	"gitlab.com/verygoodsoftwarenotvirus/blanket/config"
 	"gitlab.com/verygoodsoftwarenotvirus/blanket/lib/util"
 	"gitlab.com/verygoodsoftwarenotvirus/blanket/output/html"
 
 	"github.com/bouk/monkey"
+	"github.com/spf13/pflag"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -56,6 +59,7 @@ func TestFuncMain(t *testing.T) {
 		monkey.Patch(os.Getwd, func() (string, error) {
 			return "", errors.New("pineapple on pizza")
 		})
+		defer monkey.Unpatch(os.Getwd)
 
 		var fatalfCalled bool
 		defer func() {
@@ -73,7 +77,6 @@ func TestFuncMain(t *testing.T) {
 		main()
 		os.Args = originalArgs
 		assert.True(t, fatalfCalled, "main should call log.Fatalf() when it can't manage to retrieve the current directory")
-		monkey.Unpatch(os.Getwd)
 	})
 
 	t.Run("optimal", func(_t *testing.T) {
@@ -267,68 +270,929 @@ func TestFuncMain(t *testing.T) {
 		os.Args = originalArgs
 	})
 
-	t.Run("basic cover test", func(_t *testing.T) {
-		monkey.Patch(html.StartBrowser, func(url, os string) bool { return true })
+	t.Run("multiple packages", func(_t *testing.T) {
+		failOnFound = false
 		os.Args = []string{
 			originalArgs[0],
-			"cover",
-			fmt.Sprintf(
-				"--html=%s",
-				buildPathForExampleFiles(_t, "simple_count.coverprofile", true),
-			),
+			"analyze",
+			util.BuildExamplePackagePath(t, "simple", false),
+			util.BuildExamplePackagePath(t, "perfect", false),
 		}
 
 		main()
 		os.Args = originalArgs
-		monkey.Unpatch(html.StartBrowser)
 	})
 
-	t.Run("cover fails when it cannot parse the profile", func(_t *testing.T) {
+	t.Run("multiple packages as JSON", func(_t *testing.T) {
+		failOnFound = false
+		os.Args = []string{
+			originalArgs[0],
+			"analyze",
+			"--json",
+			util.BuildExamplePackagePath(t, "simple", false),
+			util.BuildExamplePackagePath(t, "perfect", false),
+		}
+
+		main()
+		os.Args = originalArgs
+		outputAsJSON = false
+	})
+
+	t.Run("multiple packages fail with --fail-on-found", func(_t *testing.T) {
+		os.Args = []string{
+			originalArgs[0],
+			"analyze",
+			"--fail-on-found",
+			util.BuildExamplePackagePath(t, "perfect", false),
+			util.BuildExamplePackagePath(t, "simple", false),
+		}
+		var exitCalled bool
+
+		monkey.Patch(os.Exit, func(code int) {
+			exitCalled = true
+			assert.Equal(t, 1, code, "os.Exit should be called with 1")
+		})
+
+		main()
+		assert.True(t, exitCalled, "main should call os.Exit(1) when any package has functions without direct tests")
+		os.Args = originalArgs
+		failOnFound = false
+		monkey.Unpatch(os.Exit)
+	})
+
+	t.Run("type check test", func(_t *testing.T) {
+		failOnFound = false
+		os.Args = []string{
+			originalArgs[0],
+			"analyze",
+			"--type-check",
+			fmt.Sprintf("--package=%s", util.BuildExamplePackagePath(t, "typed", false)),
+		}
+
+		main()
+		os.Args = originalArgs
+		typeCheck = false
+	})
+
+	t.Run("ignoring function values", func(_t *testing.T) {
+		failOnFound = false
+		os.Args = []string{
+			originalArgs[0],
+			"analyze",
+			"--ignore-func-values",
+			fmt.Sprintf("--package=%s", util.BuildExamplePackagePath(t, "funcvalues", false)),
+		}
+
+		main()
+		os.Args = originalArgs
+		ignoreFuncVals = false
+	})
+
+	t.Run("interface calls", func(_t *testing.T) {
+		failOnFound = false
+		os.Args = []string{
+			originalArgs[0],
+			"analyze",
+			"--interface-calls",
+			fmt.Sprintf("--package=%s", util.BuildExamplePackagePath(t, "interfaces", false)),
+		}
+
+		main()
+		os.Args = originalArgs
+		ifaceCalls = false
+	})
+
+	t.Run("counting interface calls", func(_t *testing.T) {
+		failOnFound = false
+		os.Args = []string{
+			originalArgs[0],
+			"analyze",
+			"--count-interface-calls",
+			fmt.Sprintf("--package=%s", util.BuildExamplePackagePath(t, "interfaces", false)),
+		}
+
+		main()
+		os.Args = originalArgs
+		countIface = false
+	})
+
+	t.Run("platform matrix", func(_t *testing.T) {
+		failOnFound = false
+		os.Args = []string{
+			originalArgs[0],
+			"analyze",
+			"--tags=extra",
+			"--matrix=linux/amd64,windows/amd64",
+			fmt.Sprintf("--package=%s", util.BuildExamplePackagePath(t, "platforms", false)),
+		}
+
+		main()
+		os.Args = originalArgs
+		buildTags = nil
+		matrix = nil
+	})
+
+	t.Run("invalid platform in matrix", func(_t *testing.T) {
 		var fatalCalled bool
 		defer func() {
 			// recovered from our monkey patched log.Fatal
-			r := recover()
-			if x, ok := r.(string); ok {
-				if x == "log.Fatal" {
-					fatalCalled = true
-				}
+			if r := recover(); r != nil {
+				fatalCalled = true
 			}
+			os.Args = originalArgs
+			matrix = nil
+			assert.True(t, fatalCalled, "main should call log.Fatal when a platform in the matrix is invalid")
 		}()
 
 		os.Args = []string{
 			originalArgs[0],
-			"cover",
-			`--html=""`,
+			"analyze",
+			"--matrix=linux",
+			fmt.Sprintf("--package=%s", util.BuildExamplePackagePath(t, "platforms", false)),
+		}
+
+		main()
+	})
+
+	t.Run("suppressed functions", func(_t *testing.T) {
+		failOnFound = false
+		os.Args = []string{
+			originalArgs[0],
+			"analyze",
+			fmt.Sprintf("--package=%s", util.BuildExamplePackagePath(t, "suppressed", false)),
 		}
 
 		main()
-		assert.True(t, fatalCalled)
 		os.Args = originalArgs
 	})
 
-	t.Run("cover fails when it cannot generate HTML output", func(_t *testing.T) {
-		monkey.Patch(html.Output, func(string, string, *analysis.BlanketReport) error { return errors.New("pineapple on pizza") })
+	t.Run("generated code", func(_t *testing.T) {
+		failOnFound = false
+		os.Args = []string{
+			originalArgs[0],
+			"analyze",
+			fmt.Sprintf("--package=%s", util.BuildExamplePackagePath(t, "generated", false)),
+		}
+
+		main()
+		os.Args = originalArgs
+	})
+
+	t.Run("including generated code", func(_t *testing.T) {
+		failOnFound = false
+		os.Args = []string{
+			originalArgs[0],
+			"analyze",
+			"--include-generated",
+			fmt.Sprintf("--package=%s", util.BuildExamplePackagePath(t, "generated", false)),
+		}
+
+		main()
+		os.Args = originalArgs
+		inclGenerated = false
+	})
+
+	t.Run("exported scope", func(_t *testing.T) {
+		failOnFound = false
+		os.Args = []string{
+			originalArgs[0],
+			"analyze",
+			"--scope=exported",
+			fmt.Sprintf("--package=%s", util.BuildExamplePackagePath(t, "scope", false)),
+		}
+
+		main()
+		os.Args = originalArgs
+		scope = ""
+	})
 
+	t.Run("invalid scope", func(_t *testing.T) {
 		var fatalCalled bool
 		defer func() {
 			// recovered from our monkey patched log.Fatal
 			if r := recover(); r != nil {
 				fatalCalled = true
 			}
+			os.Args = originalArgs
+			scope = ""
+			assert.True(t, fatalCalled, "main should call log.Fatal when the scope is invalid")
 		}()
 
 		os.Args = []string{
 			originalArgs[0],
-			"cover",
-			fmt.Sprintf(
-				"--html=%s",
-				buildPathForExampleFiles(_t, "simple_count.coverprofile", true),
-			),
+			"analyze",
+			"--scope=public",
+			fmt.Sprintf("--package=%s", util.BuildExamplePackagePath(t, "scope", false)),
+		}
+
+		main()
+	})
+
+	t.Run("helper settings", func(_t *testing.T) {
+		failOnFound = false
+		os.Args = []string{
+			originalArgs[0],
+			"analyze",
+			"--indirect-helper-calls",
+			"--helper-pattern=^teardown$",
+			fmt.Sprintf("--package=%s", util.BuildExamplePackagePath(t, "reachability", false)),
 		}
 
 		main()
-		assert.True(t, fatalCalled)
 		os.Args = originalArgs
-		monkey.Unpatch(html.Output)
+		indirectHelper = false
+		helperPatterns = nil
+	})
+
+	t.Run("invalid helper pattern", func(_t *testing.T) {
+		var fatalCalled bool
+		defer func() {
+			// recovered from our monkey patched log.Fatal
+			if r := recover(); r != nil {
+				fatalCalled = true
+			}
+			os.Args = originalArgs
+			helperPatterns = nil
+			assert.True(t, fatalCalled, "main should call log.Fatal when a helper pattern is invalid")
+		}()
+
+		os.Args = []string{
+			originalArgs[0],
+			"analyze",
+			"--helper-pattern=(",
+			fmt.Sprintf("--package=%s", util.BuildExamplePackagePath(t, "reachability", false)),
+		}
+
+		main()
+	})
+
+	t.Run("fails when a category is below its minimum grade", func(_t *testing.T) {
+		failOnFound = false
+		os.Args = []string{
+			originalArgs[0],
+			"analyze",
+			"--tags=integration",
+			"--min-category-grade=unit=30,integration=90",
+			fmt.Sprintf("--package=%s", util.BuildExamplePackagePath(t, "categories", false)),
+		}
+		var exitCalled bool
+
+		monkey.Patch(os.Exit, func(code int) {
+			exitCalled = true
+			assert.Equal(t, 1, code, "os.Exit should be called with 1")
+		})
+
+		main()
+		assert.True(t, exitCalled, "main should call os.Exit() when a category's grade is below its minimum")
+		os.Args = originalArgs
+		monkey.Unpatch(os.Exit)
+		buildTags = nil
+		minGrades = nil
+	})
+
+	t.Run("format flag", func(_t *testing.T) {
+		failOnFound = false
+		os.Args = []string{
+			originalArgs[0],
+			"analyze",
+			"--format=json",
+			fmt.Sprintf("--package=%s", util.BuildExamplePackagePath(t, "pad_test", false)),
+		}
+
+		main()
+		os.Args = originalArgs
+		outputFormat = ""
+	})
+
+	t.Run("sarif format", func(_t *testing.T) {
+		failOnFound = false
+		os.Args = []string{
+			originalArgs[0],
+			"analyze",
+			"--format=sarif",
+			fmt.Sprintf("--package=%s", util.BuildExamplePackagePath(t, "simple", false)),
+		}
+
+		main()
+		os.Args = originalArgs
+		outputFormat = ""
+	})
+
+	t.Run("invalid format", func(_t *testing.T) {
+		var fatalCalled bool
+		defer func() {
+			// recovered from our monkey patched log.Fatal
+			if r := recover(); r != nil {
+				fatalCalled = true
+			}
+			os.Args = originalArgs
+			outputFormat = ""
+			assert.True(t, fatalCalled, "main should call log.Fatal when the format is unknown")
+		}()
+
+		os.Args = []string{
+			originalArgs[0],
+			"analyze",
+			"--format=yaml",
+			fmt.Sprintf("--package=%s", util.BuildExamplePackagePath(t, "pad_test", false)),
+		}
+
+		main()
+	})
+
+	t.Run("fails when the grade is below the configured minimum", func(_t *testing.T) {
+		failOnFound = false
+		dir := t.TempDir()
+		util.WriteFiles(t, dir, map[string]string{"go.mod": "module example.com/thing\n", config.Filename: "min_grade: 100\n"})
+		os.Args = []string{
+			originalArgs[0],
+			"analyze",
+			fmt.Sprintf("--package=%s", util.BuildExamplePackagePath(t, "simple", false)),
+		}
+		var exitCalled bool
+
+		monkey.Patch(os.Getwd, func() (string, error) { return dir, nil })
+		monkey.Patch(os.Exit, func(code int) {
+			exitCalled = true
+			assert.Equal(t, 1, code, "os.Exit should be called with 1")
+		})
+
+		main()
+		assert.True(t, exitCalled, "main should call os.Exit() when the grade is below the configured minimum")
+		os.Args = originalArgs
+		monkey.Unpatch(os.Exit)
+		monkey.Unpatch(os.Getwd)
 	})
+
+	t.Run("baseline", func(_t *testing.T) {
+		failOnFound = false
+		baselineFile = filepath.Join(t.TempDir(), analysis.BaselineFilename)
+		examplePackage := fmt.Sprintf("--package=%s", util.BuildExamplePackagePath(t, "simple", false))
+		var exitCalled bool
+
+		monkey.Patch(os.Exit, func(code int) {
+			exitCalled = true
+		})
+
+		os.Args = []string{originalArgs[0], "baseline", "write", fmt.Sprintf("--file=%s", baselineFile), examplePackage}
+		main()
+		os.Args = []string{originalArgs[0], "analyze", fmt.Sprintf("--baseline=%s", baselineFile), examplePackage}
+		main()
+		assert.False(t, exitCalled, "main shouldn't call os.Exit when every function without direct tests is in the baseline")
+
+		if err := ioutil.WriteFile(baselineFile, []byte(`{"version": 1, "untested": {}}`), 0644); err != nil {
+			t.Logf("failing because WriteFile returned error: %v", err)
+			t.FailNow()
+		}
+		main()
+		assert.True(t, exitCalled, "main should call os.Exit when functions without direct tests are missing from the baseline")
+
+		os.Args = originalArgs
+		monkey.Unpatch(os.Exit)
+		baselinePath = ""
+		baselineFile = analysis.BaselineFilename
+	})
+
+	t.Run("tightening a baseline", func(_t *testing.T) {
+		path := filepath.Join(t.TempDir(), analysis.BaselineFilename)
+		examplePath := util.BuildExamplePackagePath(t, "simple", false)
+		contents := fmt.Sprintf(`{"version": 1, "untested": {%q: ["a", "b", "c", "wrapper"]}}`, examplePath)
+		if err := ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+			t.Logf("failing because WriteFile returned error: %v", err)
+			t.FailNow()
+		}
+
+		os.Args = []string{
+			originalArgs[0],
+			"analyze",
+			fmt.Sprintf("--baseline=%s", path),
+			"--tighten-baseline",
+			fmt.Sprintf("--package=%s", examplePath),
+		}
+		main()
+
+		actual, err := analysis.LoadBaseline(path)
+		assert.NoError(t, err)
+		if assert.NotNil(t, actual) {
+			assert.Equal(t, []string{"b"}, actual.Untested[examplePath])
+		}
+
+		os.Args = originalArgs
+		baselinePath = ""
+		tightenBase = false
+	})
+
+	t.Run("invalid baseline", func(_t *testing.T) {
+		var fatalCalled bool
+		defer func() {
+			// recovered from our monkey patched log.Fatal
+			if r := recover(); r != nil {
+				fatalCalled = true
+			}
+			os.Args = originalArgs
+			baselinePath = ""
+			assert.True(t, fatalCalled, "main should call log.Fatal when the baseline can't be loaded")
+		}()
+
+		os.Args = []string{
+			originalArgs[0],
+			"analyze",
+			fmt.Sprintf("--baseline=%s", filepath.Join(t.TempDir(), analysis.BaselineFilename)),
+			fmt.Sprintf("--package=%s", util.BuildExamplePackagePath(t, "simple", false)),
+		}
+
+		main()
+	})
+
+	t.Run("changed functions", func(_t *testing.T) {
+		failOnFound = true
+		os.Args = []string{
+			originalArgs[0],
+			"analyze",
+			"--since=HEAD",
+			fmt.Sprintf("--package=%s", util.BuildExamplePackagePath(t, "simple", false)),
+		}
+		var exitCalled bool
+
+		monkey.Patch(os.Exit, func(code int) {
+			exitCalled = true
+		})
+
+		main()
+		assert.False(t, exitCalled, "main shouldn't call os.Exit when none of the untested functions changed")
+		os.Args = originalArgs
+		monkey.Unpatch(os.Exit)
+		failOnFound = false
+		since = ""
+	})
+
+	t.Run("--since and --staged together", func(_t *testing.T) {
+		var fatalCalled bool
+		defer func() {
+			// recovered from our monkey patched log.Fatal
+			if r := recover(); r != nil {
+				fatalCalled = true
+			}
+			os.Args = originalArgs
+			since = ""
+			staged = false
+			assert.True(t, fatalCalled, "main should call log.Fatal when both --since and --staged are passed")
+		}()
+
+		os.Args = []string{
+			originalArgs[0],
+			"analyze",
+			"--since=HEAD",
+			"--staged",
+			fmt.Sprintf("--package=%s", util.BuildExamplePackagePath(t, "simple", false)),
+		}
+
+		main()
+	})
+
+	t.Run("compare without --base", func(_t *testing.T) {
+		var fatalCalled bool
+		defer func() {
+			// recovered from our monkey patched log.Fatal
+			if r := recover(); r != nil {
+				fatalCalled = true
+			}
+			os.Args = originalArgs
+			assert.True(t, fatalCalled, "main should call log.Fatal when --base is missing")
+		}()
+
+		os.Args = []string{originalArgs[0], "compare"}
+
+		main()
+	})
+
+	t.Run("compare with a nonexistent revision", func(_t *testing.T) {
+		var fatalCalled bool
+		defer func() {
+			// recovered from our monkey patched log.Fatal
+			if r := recover(); r != nil {
+				fatalCalled = true
+			}
+			os.Args = originalArgs
+			compareBase = ""
+			assert.True(t, fatalCalled, "main should call log.Fatal when a revision doesn't exist")
+		}()
+
+		os.Args = []string{originalArgs[0], "compare", "--base=absolutelynosuchrevision"}
+
+		main()
+	})
+
+	t.Run("diff", func(_t *testing.T) {
+		dir := t.TempDir()
+		oldPath, newPath := filepath.Join(dir, "old.json"), filepath.Join(dir, "new.json")
+		reports := map[string]string{
+			oldPath: `{"package": "example/api", "declared": 2, "called": 2, "score": 100, "functions": [{"name": "A", "tested": true}, {"name": "B", "tested": true}]}`,
+			newPath: `{"package": "example/api", "declared": 2, "called": 1, "score": 50, "functions": [{"name": "A", "tested": true}, {"name": "B", "tested": false}]}`,
+		}
+		for path, contents := range reports {
+			if err := ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+				t.Logf("failing because WriteFile returned error: %v", err)
+				t.FailNow()
+			}
+		}
+		var exitCalled bool
+
+		monkey.Patch(os.Exit, func(code int) {
+			exitCalled = true
+			assert.Equal(t, 1, code, "os.Exit should be called with 1")
+		})
+
+		for _, format := range []string{"text", "json", "markdown"} {
+			exitCalled = false
+			os.Args = []string{originalArgs[0], "diff", fmt.Sprintf("--format=%s", format), oldPath, newPath}
+			main()
+			assert.True(t, exitCalled, "main should call os.Exit when a function lost its direct test")
+		}
+
+		exitCalled = false
+		os.Args = []string{originalArgs[0], "diff", "--format=text", "--fail-on=score", "--score-tolerance=50", oldPath, newPath}
+		main()
+		assert.False(t, exitCalled, "main shouldn't call os.Exit when the grade dropped within the tolerance")
+
+		os.Args = originalArgs
+		monkey.Unpatch(os.Exit)
+		outputFormat = ""
+		failOn = []string{"untested"}
+		scoreTolerance = 0
+	})
+
+	t.Run("diff with an invalid regression rule", func(_t *testing.T) {
+		var fatalCalled bool
+		defer func() {
+			// recovered from our monkey patched log.Fatal
+			if r := recover(); r != nil {
+				fatalCalled = true
+			}
+			os.Args = originalArgs
+			failOn = []string{"untested"}
+			assert.True(t, fatalCalled, "main should call log.Fatal when the regression rule is invalid")
+		}()
+
+		os.Args = []string{originalArgs[0], "diff", "--fail-on=coverage", "old.json", "new.json"}
+
+		main()
+	})
+
+	t.Run("validating a configuration file", func(_t *testing.T) {
+		configPath := filepath.Join(t.TempDir(), config.Filename)
+		if err := ioutil.WriteFile(configPath, []byte("min_grade: 80\n"), 0644); err != nil {
+			t.Logf("failing because WriteFile returned error: %v", err)
+			t.FailNow()
+		}
+		var exitCalled bool
+
+		monkey.Patch(os.Exit, func(code int) {
+			exitCalled = true
+		})
+
+		os.Args = []string{originalArgs[0], "config", "validate", configPath}
+		main()
+		assert.False(t, exitCalled, "main shouldn't call os.Exit when the configuration file is valid")
+		os.Args = originalArgs
+		monkey.Unpatch(os.Exit)
+	})
+
+	t.Run("validating an invalid configuration file", func(_t *testing.T) {
+		configPath := filepath.Join(t.TempDir(), config.Filename)
+		if err := ioutil.WriteFile(configPath, []byte("min_grade: 800\nformats: text\n"), 0644); err != nil {
+			t.Logf("failing because WriteFile returned error: %v", err)
+			t.FailNow()
+		}
+		var exitCalled bool
+
+		monkey.Patch(os.Exit, func(code int) {
+			exitCalled = true
+			assert.Equal(t, 1, code, "os.Exit should be called with 1")
+		})
+
+		os.Args = []string{originalArgs[0], "config", "validate", configPath}
+		main()
+		assert.True(t, exitCalled, "main should call os.Exit() when the configuration file is invalid")
+		os.Args = originalArgs
+		monkey.Unpatch(os.Exit)
+	})
+
+	t.Run("basic cover test", func(_t *testing.T) {
+		monkey.Patch(html.StartBrowser, func(url, os string) bool { return true })
+		os.Args = []string{
+			originalArgs[0],
+			"cover",
+			fmt.Sprintf(
+				"--html=%s",
+				buildPathForExampleFiles(_t, "simple_count.coverprofile", true),
+			),
+		}
+
+		main()
+		os.Args = originalArgs
+		monkey.Unpatch(html.StartBrowser)
+	})
+
+	t.Run("cover fails when it cannot parse the profile", func(_t *testing.T) {
+		var fatalCalled bool
+		defer func() {
+			// recovered from our monkey patched log.Fatal
+			r := recover()
+			if x, ok := r.(string); ok {
+				if x == "log.Fatal" {
+					fatalCalled = true
+				}
+			}
+		}()
+
+		os.Args = []string{
+			originalArgs[0],
+			"cover",
+			`--html=""`,
+		}
+
+		main()
+		assert.True(t, fatalCalled)
+		os.Args = originalArgs
+	})
+
+	t.Run("cover fails when it cannot generate HTML output", func(_t *testing.T) {
+		monkey.Patch(html.Output, func(string, string, *analysis.BlanketReport) error { return errors.New("pineapple on pizza") })
+
+		var fatalCalled bool
+		defer func() {
+			// recovered from our monkey patched log.Fatal
+			if r := recover(); r != nil {
+				fatalCalled = true
+			}
+		}()
+
+		os.Args = []string{
+			originalArgs[0],
+			"cover",
+			fmt.Sprintf(
+				"--html=%s",
+				buildPathForExampleFiles(_t, "simple_count.coverprofile", true),
+			),
+		}
+
+		main()
+		assert.True(t, fatalCalled)
+		os.Args = originalArgs
+		monkey.Unpatch(html.Output)
+	})
+}
+
+func TestParseCategoryMinimums(t *testing.T) {
+	t.Run("normal", func(_t *testing.T) {
+		actual, err := parseCategoryMinimums([]string{"unit=80", "integration && !race=50"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]int{"unit": 80, "integration && !race": 50}, actual)
+	})
+
+	t.Run("invalid", func(_t *testing.T) {
+		for _, input := range []string{"unit", "=80", "unit=eighty"} {
+			_, err := parseCategoryMinimums([]string{input})
+			assert.Error(t, err, "expected an error for %q", input)
+		}
+	})
+}
+
+func TestReportFormat(t *testing.T) {
+	t.Run("defaults to text", func(_t *testing.T) {
+		actual, err := reportFormat(&config.Config{}, "text", "json")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "text", actual)
+	})
+
+	t.Run("flags take precedence over the configuration file", func(_t *testing.T) {
+		actual, err := reportFormat(&config.Config{Format: "json"}, "text", "json")
+		assert.NoError(t, err)
+		assert.Equal(t, "json", actual)
+
+		outputFormat = "text"
+		actual, err = reportFormat(&config.Config{Format: "json"}, "text", "json")
+		assert.NoError(t, err)
+		assert.Equal(t, "text", actual)
+
+		outputAsJSON = true
+		actual, err = reportFormat(&config.Config{}, "text", "json")
+		assert.NoError(t, err)
+		assert.Equal(t, "json", actual)
+
+		outputFormat = ""
+		outputAsJSON = false
+	})
+
+	t.Run("configured format the command doesn't support", func(_t *testing.T) {
+		actual, err := reportFormat(&config.Config{Format: "sarif"}, "text", "json")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "text", actual)
+	})
+
+	t.Run("unsupported format", func(_t *testing.T) {
+		outputFormat = "sarif"
+		_, err := reportFormat(&config.Config{}, "text", "markdown")
+		assert.Error(t, err)
+		outputFormat = ""
+	})
+}
+
+func TestRenderTemplate(t *testing.T) {
+	t.Run("normal", func(_t *testing.T) {
+		actual := renderTemplate(generatedTmpl, struct{ GeneratedCount int }{2})
+		assert.Equal(t, "Skipped 2 functions in generated files.\n\n", actual)
+	})
+
+	t.Run("without anything to report", func(_t *testing.T) {
+		actual := renderTemplate(generatedTmpl, struct{ GeneratedCount int }{0})
+		assert.Empty(t, actual)
+	})
+}
+
+func TestRenderReport(t *testing.T) {
+	summary, err := analysis.AnalyzePackages(
+		analysis.Options{},
+		util.BuildExamplePackagePath(t, "simple", false),
+		util.BuildExamplePackagePath(t, "perfect", false),
+	)
+	if err != nil {
+		t.Logf("failing because AnalyzePackages returned error: %v", err)
+		t.FailNow()
+	}
+
+	t.Run("with functions without direct unit tests", func(_t *testing.T) {
+		actual := renderReport(summary.Packages[0], true)
+
+		assert.Contains(t, actual, "Functions without direct unit tests:")
+		assert.Contains(t, actual, "b on line 7")
+		assert.Contains(t, actual, "(3/4 functions)")
+	})
+
+	t.Run("with a perfect score", func(_t *testing.T) {
+		actual := renderReport(summary.Packages[1], false)
+
+		assert.NotContains(t, actual, "Functions without direct unit tests:")
+		assert.Contains(t, actual, "(4/4 functions)")
+	})
+}
+
+func TestAddAnalysisFlags(t *testing.T) {
+	flags := pflag.NewFlagSet("example", pflag.ContinueOnError)
+	addAnalysisFlags(flags)
+
+	for _, name := range []string{"type-check", "ignore-func-values", "interface-calls", "count-interface-calls", "helper-pattern", "indirect-helper-calls", "scope", "include-generated", "tags", "goos", "goarch", "matrix", "package"} {
+		assert.NotNil(t, flags.Lookup(name), "expected %s flag to be added", name)
+	}
+	assert.NotNil(t, flags.ShorthandLookup("t"))
+	assert.Equal(t, ".", flags.Lookup("package").DefValue)
+}
+
+func TestConfigPathFor(t *testing.T) {
+	t.Run("from the arguments", func(_t *testing.T) {
+		actual, err := configPathFor([]string{"custom.yaml"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "custom.yaml", actual)
+	})
+
+	t.Run("from the working directory", func(_t *testing.T) {
+		dir := t.TempDir()
+		util.WriteFiles(t, dir, map[string]string{"go.mod": "module example.com/thing\n", config.Filename: "min_grade: 80\n", "sub/sub.go": "package sub\n"})
+		monkey.Patch(os.Getwd, func() (string, error) {
+			return filepath.Join(dir, "sub"), nil
+		})
+
+		actual, err := configPathFor(nil)
+
+		assert.NoError(t, err)
+		assert.Equal(t, filepath.Join(dir, config.Filename), actual)
+		monkey.Unpatch(os.Getwd)
+	})
+
+	t.Run("without a configuration file", func(_t *testing.T) {
+		dir := t.TempDir()
+		util.WriteFiles(t, dir, map[string]string{"go.mod": "module example.com/thing\n"})
+		monkey.Patch(os.Getwd, func() (string, error) {
+			return dir, nil
+		})
+
+		_, err := configPathFor(nil)
+
+		assert.Error(t, err)
+		monkey.Unpatch(os.Getwd)
+	})
+
+	t.Run("with os.Getwd error", func(_t *testing.T) {
+		monkey.Patch(os.Getwd, func() (string, error) {
+			return "", errors.New("pineapple on pizza")
+		})
+
+		_, err := configPathFor(nil)
+
+		assert.Error(t, err)
+		monkey.Unpatch(os.Getwd)
+	})
+}
+
+func TestLoadConfig(t *testing.T) {
+	t.Run("normal", func(_t *testing.T) {
+		dir := t.TempDir()
+		util.WriteFiles(t, dir, map[string]string{"go.mod": "module example.com/thing\n", config.Filename: "min_grade: 80\n"})
+		monkey.Patch(os.Getwd, func() (string, error) {
+			return dir, nil
+		})
+
+		actual, err := loadConfig()
+
+		assert.NoError(t, err)
+		if assert.NotNil(t, actual) {
+			assert.Equal(t, 80, actual.MinGrade)
+			assert.Equal(t, dir, actual.Root)
+		}
+		monkey.Unpatch(os.Getwd)
+	})
+
+	t.Run("without a configuration file", func(_t *testing.T) {
+		dir := t.TempDir()
+		monkey.Patch(os.Getwd, func() (string, error) {
+			return dir, nil
+		})
+
+		actual, err := loadConfig()
+
+		assert.NoError(t, err)
+		assert.Equal(t, &config.Config{}, actual)
+		monkey.Unpatch(os.Getwd)
+	})
+
+	t.Run("with an invalid configuration file", func(_t *testing.T) {
+		dir := t.TempDir()
+		util.WriteFiles(t, dir, map[string]string{"go.mod": "module example.com/thing\n", config.Filename: "min_grade: 800\n"})
+		monkey.Patch(os.Getwd, func() (string, error) {
+			return dir, nil
+		})
+
+		_, err := loadConfig()
+
+		assert.Error(t, err)
+		monkey.Unpatch(os.Getwd)
+	})
+
+	t.Run("with os.Getwd error", func(_t *testing.T) {
+		monkey.Patch(os.Getwd, func() (string, error) {
+			return "", errors.New("pineapple on pizza")
+		})
+
+		_, err := loadConfig()
+
+		assert.Error(t, err)
+		monkey.Unpatch(os.Getwd)
+	})
+}
+
+func TestSarifRoot(t *testing.T) {
+	t.Run("outside of a git repository", func(_t *testing.T) {
+		dir := t.TempDir()
+		monkey.Patch(os.Getwd, func() (string, error) {
+			return dir, nil
+		})
+
+		assert.Equal(t, dir, sarifRoot())
+		monkey.Unpatch(os.Getwd)
+	})
+
+	t.Run("with os.Getwd error", func(_t *testing.T) {
+		monkey.Patch(os.Getwd, func() (string, error) {
+			return "", errors.New("pineapple on pizza")
+		})
+
+		assert.Empty(t, sarifRoot())
+		monkey.Unpatch(os.Getwd)
+	})
+}
+
+func TestAnalyzeOptions(t *testing.T) {
+	cfg := &config.Config{
+		Root:        "/src/project",
+		DirectCalls: config.DirectCalls{TypeCheck: true, HelperPatterns: []string{"^setup"}},
+	}
+	ignoreFuncVals = true
+	helperPatterns = []string{"^teardown$"}
+
+	actual, err := analyzeOptions(cfg)
+
+	assert.NoError(t, err)
+	assert.True(t, actual.TypeCheck)
+	assert.True(t, actual.IgnoreFuncValues)
+	assert.Equal(t, "/src/project", actual.Root)
+	assert.Len(t, actual.HelperPatterns, 2)
+	assert.Equal(t, analysis.ScopeAll, actual.Scope)
+
+	ignoreFuncVals = false
+	helperPatterns = nil
 }
@@ -0,0 +1,274 @@
+// Package config loads the per-project settings blanket reads from a .blanket.yaml file at the root of a module.
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gitlab.com/verygoodsoftwarenotvirus/blanket/analysis"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// Filename is the name of the configuration file blanket looks for.
+const Filename = ".blanket.yaml"
+
+// rootMarkers are the files that mark the root of a project, where the configuration file lives.
+var rootMarkers = []string{"go.mod", "Gopkg.toml"}
+
+// formats are the output formats the configuration file can pick as the default.
+var formats = map[string]bool{"text": true, "json": true, "sarif": true}
+
+// Config is the contents of a configuration file.
+type Config struct {
+	// Include and Exclude decide which functions are analyzed, by the path of the file they're declared
+	// in (relative to the root of the project) and by their name. See analysis.Options for details.
+	Include Patterns `yaml:"include"`
+	Exclude Patterns `yaml:"exclude"`
+
+	// IncludeGenerated analyzes the functions declared in generated files, which are skipped otherwise.
+	IncludeGenerated bool `yaml:"include_generated"`
+
+	// Scope is exported, unexported or all (the default).
+	Scope string `yaml:"scope"`
+
+	// MinGrade is the minimum grade for the whole run, and Directories the minimum grade for the
+	// packages in individual directories, relative to the root of the project.
+	MinGrade    int            `yaml:"min_grade"`
+	Directories map[string]int `yaml:"directories"`
+
+	DirectCalls DirectCalls   `yaml:"direct_calls"`
+	Suppress    []Suppression `yaml:"suppress"`
+
+	// Format is the default output format, either text, json or sarif.
+	Format string `yaml:"format"`
+
+	// Root is the directory the configuration file was loaded from.
+	Root string `yaml:"-"`
+}
+
+// Patterns are path and name patterns. Paths use path.Match syntax (with `dir/...` matching everything
+// under dir), and names are regular expressions.
+type Patterns struct {
+	Paths []string `yaml:"paths"`
+	Names []string `yaml:"names"`
+}
+
+// DirectCalls holds the settings for what counts as a direct call. They mirror the analyze command's flags.
+type DirectCalls struct {
+	TypeCheck           bool     `yaml:"type_check"`
+	IgnoreFuncValues    bool     `yaml:"ignore_func_values"`
+	InterfaceCalls      bool     `yaml:"interface_calls"`
+	CountInterfaceCalls bool     `yaml:"count_interface_calls"`
+	HelperPatterns      []string `yaml:"helper_patterns"`
+	IndirectHelperCalls bool     `yaml:"indirect_helper_calls"`
+}
+
+// Suppression suppresses the functions matching a name pattern, a path pattern, or both, the same
+// way a `//blanket:ignore` directive does. Until is optional, and in the YYYY-MM-DD format.
+type Suppression struct {
+	Name   string `yaml:"name"`
+	Path   string `yaml:"path"`
+	Reason string `yaml:"reason"`
+	Until  string `yaml:"until"`
+}
+
+// ValidationError lists every problem found in a configuration file.
+type ValidationError struct {
+	Problems []string
+}
+
+func (v *ValidationError) Error() string {
+	return fmt.Sprintf("invalid configuration: %s", strings.Join(v.Problems, "; "))
+}
+
+// Find returns the path of the configuration file for a given directory, which lives at the root of the
+// project the directory is in (the closest directory with a go.mod or Gopkg.toml file). It returns an
+// empty string if the directory isn't in a project, or the project doesn't have a configuration file.
+func Find(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", errors.Wrap(err, "finding configuration file")
+	}
+
+	for {
+		for _, marker := range rootMarkers {
+			if _, err := os.Stat(filepath.Join(dir, marker)); err == nil {
+				configPath := filepath.Join(dir, Filename)
+				if _, err := os.Stat(configPath); err != nil {
+					return "", nil
+				}
+				return configPath, nil
+			}
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// Load reads, parses and validates the configuration file at a given path.
+func Load(configPath string) (*Config, error) {
+	data, err := ioutil.ReadFile(configPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading configuration file")
+	}
+
+	c, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	c.Root = filepath.Dir(configPath)
+	return c, nil
+}
+
+// Parse parses and validates the contents of a configuration file. Unknown keys are errors, which are reported
+// along with every problem Validate finds in the rest of the file.
+func Parse(data []byte) (*Config, error) {
+	c := &Config{}
+	var problems []string
+	if err := yaml.UnmarshalStrict(data, c); err != nil {
+		typeErr, ok := err.(*yaml.TypeError)
+		if !ok {
+			return nil, errors.Wrap(err, "parsing configuration file")
+		}
+		problems = typeErr.Errors
+
+		// decode the file again without the unknown keys getting in the way, so everything else gets validated too.
+		// Any type errors this finds were already reported above.
+		c = &Config{}
+		yaml.Unmarshal(data, c)
+	}
+
+	if err := c.Validate(); err != nil {
+		validationErr, ok := err.(*ValidationError)
+		if !ok {
+			return nil, err
+		}
+		problems = append(problems, validationErr.Problems...)
+	}
+
+	if len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
+	}
+	return c, nil
+}
+
+// Validate checks every pattern, grade, date and format in the configuration.
+func (c *Config) Validate() error {
+	var problems []string
+	checkPaths := func(key string, patterns []string) {
+		for _, p := range patterns {
+			if _, err := path.Match(strings.TrimSuffix(p, "/..."), ""); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: invalid path pattern %q", key, p))
+			}
+		}
+	}
+	checkNames := func(key string, patterns []string) {
+		for _, p := range patterns {
+			if _, err := regexp.Compile(p); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: invalid name pattern %q: %v", key, p, err))
+			}
+		}
+	}
+	checkGrade := func(key string, grade int) {
+		if grade < 0 || grade > 100 {
+			problems = append(problems, fmt.Sprintf("%s: grade %d isn't between 0 and 100", key, grade))
+		}
+	}
+
+	checkPaths("include.paths", c.Include.Paths)
+	checkNames("include.names", c.Include.Names)
+	checkPaths("exclude.paths", c.Exclude.Paths)
+	checkNames("exclude.names", c.Exclude.Names)
+	checkNames("direct_calls.helper_patterns", c.DirectCalls.HelperPatterns)
+
+	checkGrade("min_grade", c.MinGrade)
+	var dirs []string
+	for dir := range c.Directories {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	for _, dir := range dirs {
+		checkGrade(fmt.Sprintf("directories.%s", dir), c.Directories[dir])
+	}
+
+	for i, s := range c.Suppress {
+		key := fmt.Sprintf("suppress[%d]", i)
+		if s.Name == "" && s.Path == "" {
+			problems = append(problems, fmt.Sprintf("%s: needs a name or a path", key))
+		}
+		checkNames(key+".name", []string{s.Name})
+		if s.Path != "" {
+			checkPaths(key+".path", []string{s.Path})
+		}
+		if _, err := time.Parse("2006-01-02", s.Until); s.Until != "" && err != nil {
+			problems = append(problems, fmt.Sprintf("%s.until: invalid date %q, expected YYYY-MM-DD", key, s.Until))
+		}
+	}
+
+	if _, err := analysis.ParseScope(c.Scope); err != nil {
+		problems = append(problems, fmt.Sprintf("scope: %v", err))
+	}
+
+	if c.Format != "" && !formats[c.Format] {
+		problems = append(problems, fmt.Sprintf("format: unknown format %q", c.Format))
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// Options returns the analysis options the configuration sets. It assumes the configuration is valid.
+func (c *Config) Options() analysis.Options {
+	scope, _ := analysis.ParseScope(c.Scope)
+	opts := analysis.Options{
+		TypeCheck:           c.DirectCalls.TypeCheck,
+		IgnoreFuncValues:    c.DirectCalls.IgnoreFuncValues,
+		InterfaceCalls:      c.DirectCalls.InterfaceCalls,
+		CountInterfaceCalls: c.DirectCalls.CountInterfaceCalls,
+		IndirectHelperCalls: c.DirectCalls.IndirectHelperCalls,
+		HelperPatterns:      compile(c.DirectCalls.HelperPatterns),
+		IncludeGenerated:    c.IncludeGenerated,
+		Scope:               scope,
+		Root:                c.Root,
+		IncludePaths:        c.Include.Paths,
+		ExcludePaths:        c.Exclude.Paths,
+		IncludeNames:        compile(c.Include.Names),
+		ExcludeNames:        compile(c.Exclude.Names),
+	}
+
+	for _, s := range c.Suppress {
+		rule := analysis.SuppressionRule{Path: s.Path}
+		rule.Reason = s.Reason
+		if s.Name != "" {
+			rule.Name = regexp.MustCompile(s.Name)
+		}
+		if s.Until != "" {
+			rule.Until, _ = time.ParseInLocation("2006-01-02", s.Until, time.Local)
+		}
+		opts.SuppressionRules = append(opts.SuppressionRules, rule)
+	}
+	return opts
+}
+
+func compile(patterns []string) []*regexp.Regexp {
+	var out []*regexp.Regexp
+	for _, p := range patterns {
+		out = append(out, regexp.MustCompile(p))
+	}
+	return out
+}
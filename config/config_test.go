@@ -0,0 +1,244 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gitlab.com/verygoodsoftwarenotvirus/blanket/analysis"
+	"gitlab.com/verygoodsoftwarenotvirus/blanket/lib/util"
+
+	"github.com/stretchr/testify/assert"
+)
+
+////////////////////////////////////////////////////////
+//                                                    //
+//               Test Helper Functions                //
+//                                                    //
+////////////////////////////////////////////////////////
+
+const exampleConfig = `
+include:
+  paths: ["./..."]
+exclude:
+  paths: ["internal/mocks/...", "*_gen.go"]
+  names: ['\.String$']
+min_grade: 70
+directories:
+  internal/legacy: 30
+direct_calls:
+  type_check: true
+  helper_patterns: ["^setup"]
+suppress:
+  - name: "^hostname$"
+    reason: thin wrapper around os.Hostname
+    until: 2027-01-01
+  - path: internal/osutil/...
+include_generated: true
+scope: exported
+format: json
+`
+
+////////////////////////////////////////////////////////
+//                                                    //
+//                   Actual Tests                     //
+//                                                    //
+////////////////////////////////////////////////////////
+
+func TestFind(t *testing.T) {
+	t.Run("from a subdirectory of the module", func(_t *testing.T) {
+		dir := t.TempDir()
+		util.WriteFiles(t, dir, map[string]string{
+			"go.mod":       "module example.com/thing\n",
+			Filename:       "min_grade: 50\n",
+			"sub/pkg/a.go": "package pkg\n",
+		})
+
+		actual, err := Find(filepath.Join(dir, "sub", "pkg"))
+
+		assert.NoError(t, err)
+		assert.Equal(t, filepath.Join(dir, Filename), actual)
+	})
+
+	t.Run("stops at the closest module root", func(_t *testing.T) {
+		dir := t.TempDir()
+		util.WriteFiles(t, dir, map[string]string{
+			"go.mod":        "module example.com/thing\n",
+			Filename:        "min_grade: 50\n",
+			"nested/go.mod": "module example.com/nested\n",
+		})
+
+		actual, err := Find(filepath.Join(dir, "nested"))
+
+		assert.NoError(t, err)
+		assert.Empty(t, actual, "expected the configuration of another module not to apply")
+	})
+
+	t.Run("dep project", func(_t *testing.T) {
+		dir := t.TempDir()
+		util.WriteFiles(t, dir, map[string]string{
+			"Gopkg.toml": "",
+			Filename:     "min_grade: 50\n",
+		})
+
+		actual, err := Find(dir)
+
+		assert.NoError(t, err)
+		assert.Equal(t, filepath.Join(dir, Filename), actual)
+	})
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("normal", func(_t *testing.T) {
+		dir := t.TempDir()
+		util.WriteFiles(t, dir, map[string]string{Filename: exampleConfig})
+
+		actual, err := Load(filepath.Join(dir, Filename))
+
+		assert.NoError(t, err)
+		if assert.NotNil(t, actual) {
+			assert.Equal(t, dir, actual.Root)
+			assert.Equal(t, 70, actual.MinGrade)
+			assert.Equal(t, map[string]int{"internal/legacy": 30}, actual.Directories)
+			assert.Equal(t, "json", actual.Format)
+			assert.True(t, actual.DirectCalls.TypeCheck)
+			assert.Len(t, actual.Suppress, 2)
+		}
+	})
+
+	t.Run("nonexistent file", func(_t *testing.T) {
+		_, err := Load(filepath.Join(t.TempDir(), Filename))
+		assert.Error(t, err)
+	})
+}
+
+func TestParse(t *testing.T) {
+	t.Run("unknown keys", func(_t *testing.T) {
+		_, err := Parse([]byte("min_grade: 50\nmin_grades: 60\ndirect_calls:\n  typecheck: true\n"))
+
+		if assert.IsType(t, &ValidationError{}, err) {
+			assert.Len(t, err.(*ValidationError).Problems, 2)
+		}
+	})
+
+	t.Run("unknown keys and invalid patterns", func(_t *testing.T) {
+		_, err := Parse([]byte("bogus: 1\nexclude:\n  names: [\"(\"]\n"))
+
+		if assert.IsType(t, &ValidationError{}, err) {
+			problems := err.(*ValidationError).Problems
+			if assert.Len(t, problems, 2) {
+				assert.Contains(t, problems[0], "field bogus not found")
+				assert.Contains(t, problems[1], "exclude.names")
+			}
+		}
+	})
+
+	t.Run("invalid yaml", func(_t *testing.T) {
+		_, err := Parse([]byte("min_grade: [50"))
+
+		_, isValidationError := err.(*ValidationError)
+		assert.Error(t, err)
+		assert.False(t, isValidationError, "expected syntax errors to be reported as they are")
+	})
+}
+
+func TestValidate(t *testing.T) {
+	t.Run("valid", func(_t *testing.T) {
+		c, err := Parse([]byte(exampleConfig))
+
+		assert.NoError(t, err)
+		assert.NoError(t, c.Validate())
+	})
+
+	t.Run("invalid", func(_t *testing.T) {
+		c := &Config{
+			Include:     Patterns{Paths: []string{"["}, Names: []string{"("}},
+			Exclude:     Patterns{Names: []string{"ok", "*"}},
+			MinGrade:    101,
+			Directories: map[string]int{"api": -1},
+			DirectCalls: DirectCalls{HelperPatterns: []string{"["}},
+			Suppress: []Suppression{
+				{Reason: "nothing to match"},
+				{Name: "a", Until: "someday"},
+			},
+			Scope:  "public",
+			Format: "xml",
+		}
+
+		err := c.Validate()
+
+		if assert.IsType(t, &ValidationError{}, err) {
+			expected := []string{
+				`include.paths: invalid path pattern "["`,
+				"include.names",
+				"exclude.names",
+				"direct_calls.helper_patterns",
+				"min_grade: grade 101 isn't between 0 and 100",
+				"directories.api: grade -1 isn't between 0 and 100",
+				"suppress[0]: needs a name or a path",
+				`suppress[1].until: invalid date "someday", expected YYYY-MM-DD`,
+				`scope: invalid scope "public"`,
+				`format: unknown format "xml"`,
+			}
+			problems := err.(*ValidationError).Problems
+			if assert.Len(t, problems, len(expected)) {
+				for i := range expected {
+					assert.Contains(t, problems[i], expected[i])
+				}
+			}
+		}
+	})
+}
+
+func TestValidationErrorError(t *testing.T) {
+	err := &ValidationError{Problems: []string{"min_grade: grade 101 isn't between 0 and 100", `scope: invalid scope "public"`}}
+
+	expected := `invalid configuration: min_grade: grade 101 isn't between 0 and 100; scope: invalid scope "public"`
+	assert.Equal(t, expected, err.Error())
+}
+
+func TestCompile(t *testing.T) {
+	t.Run("normal", func(_t *testing.T) {
+		actual := compile([]string{"^setup", `\.String$`})
+
+		if assert.Len(t, actual, 2) {
+			assert.Equal(t, "^setup", actual[0].String())
+			assert.True(t, actual[1].MatchString("Thing.String"))
+		}
+	})
+
+	t.Run("without any patterns", func(_t *testing.T) {
+		assert.Empty(t, compile(nil))
+	})
+}
+
+func TestConfigOptions(t *testing.T) {
+	c, err := Parse([]byte(exampleConfig))
+	if err != nil {
+		t.Logf("failing because Parse returned error: %v", err)
+		t.FailNow()
+	}
+	c.Root = "/src/project"
+
+	actual := c.Options()
+
+	assert.True(t, actual.TypeCheck)
+	assert.True(t, actual.IncludeGenerated)
+	assert.Equal(t, analysis.ScopeExported, actual.Scope)
+	assert.Equal(t, "/src/project", actual.Root)
+	assert.Equal(t, []string{"./..."}, actual.IncludePaths)
+	assert.Equal(t, []string{"internal/mocks/...", "*_gen.go"}, actual.ExcludePaths)
+	if assert.Len(t, actual.ExcludeNames, 1) {
+		assert.True(t, actual.ExcludeNames[0].MatchString("Thing.String"))
+	}
+	if assert.Len(t, actual.HelperPatterns, 1) {
+		assert.True(t, actual.HelperPatterns[0].MatchString("setupServer"))
+	}
+	if assert.Len(t, actual.SuppressionRules, 2) {
+		assert.True(t, actual.SuppressionRules[0].Name.MatchString("hostname"))
+		assert.Equal(t, "thin wrapper around os.Hostname", actual.SuppressionRules[0].Reason)
+		assert.Equal(t, time.Date(2027, 1, 1, 0, 0, 0, 0, time.Local), actual.SuppressionRules[0].Until)
+		assert.Nil(t, actual.SuppressionRules[1].Name)
+		assert.Equal(t, "internal/osutil/...", actual.SuppressionRules[1].Path)
+	}
+}
@@ -0,0 +1,11 @@
+//go:build integration
+
+package categories
+
+import (
+	"testing"
+)
+
+func TestFetchAndParse(t *testing.T) {
+	Parse(Fetch("http://example.com"))
+}
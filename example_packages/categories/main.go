@@ -0,0 +1,11 @@
+package categories
+
+func Parse(s string) string {
+	return s
+}
+
+func Fetch(url string) string {
+	return url
+}
+
+func Sync() {}
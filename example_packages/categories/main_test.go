@@ -0,0 +1,9 @@
+package categories
+
+import (
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	Parse("x")
+}
@@ -0,0 +1,40 @@
+package embedding
+
+type store struct {
+	data map[string]string
+}
+
+func (s *store) get(key string) string {
+	return s.data[key]
+}
+
+type Cache struct {
+	*store
+	size int
+}
+
+func (c *Cache) Lookup(key string) string {
+	return c.get(key)
+}
+
+func (c *Cache) Name() string {
+	return "cache"
+}
+
+type Service struct {
+	*Cache
+}
+
+func (s *Service) Name() string {
+	return "service"
+}
+
+type node struct {
+	*node
+}
+
+func (n *node) walk() {}
+
+func NewService() *Service {
+	return &Service{Cache: &Cache{store: &store{data: map[string]string{}}}}
+}
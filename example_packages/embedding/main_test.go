@@ -0,0 +1,22 @@
+package embedding
+
+import (
+	"testing"
+)
+
+func TestNewService(t *testing.T) {
+	NewService()
+}
+
+func TestPromotedMethods(t *testing.T) {
+	svc := NewService()
+	svc.Lookup("key")
+	svc.get("key")
+	svc.Name()
+}
+
+func TestSelfEmbedding(t *testing.T) {
+	n := node{}
+	n.walk()
+	n.node.walk()
+}
@@ -0,0 +1,22 @@
+package external_test
+
+import (
+	"testing"
+
+	ext "gitlab.com/verygoodsoftwarenotvirus/blanket/example_packages/external"
+)
+
+func TestGreet(t *testing.T) {
+	ext.Greet("world")
+}
+
+func TestStore(t *testing.T) {
+	s := ext.NewStore()
+	s.Put("a", "b")
+}
+
+func TestGet(t *testing.T) {
+	var s *ext.Store
+	s = ext.NewStore()
+	s.Get("a")
+}
@@ -0,0 +1,39 @@
+package external
+
+import (
+	"strings"
+)
+
+// Store is an exported type whose methods are tested from the external test package.
+type Store struct {
+	items map[string]string
+}
+
+// NewStore builds a Store.
+func NewStore() *Store {
+	return &Store{items: map[string]string{}}
+}
+
+// Get returns a value from the store.
+func (s *Store) Get(key string) string {
+	return s.items[key]
+}
+
+// Put adds a value to the store.
+func (s *Store) Put(key, value string) {
+	s.items[key] = normalize(value)
+}
+
+// Delete removes a value from the store.
+func (s *Store) Delete(key string) {
+	delete(s.items, key)
+}
+
+// Greet greets someone.
+func Greet(name string) string {
+	return "hello, " + normalize(name)
+}
+
+func normalize(s string) string {
+	return strings.TrimSpace(s)
+}
@@ -0,0 +1,9 @@
+package external
+
+import (
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	normalize(" x ")
+}
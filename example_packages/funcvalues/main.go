@@ -0,0 +1,33 @@
+package funcvalues
+
+import (
+	"strings"
+)
+
+type handler func() string
+
+func Parse(s string) int {
+	return len(s)
+}
+
+func A() string {
+	return "A"
+}
+
+func B() string {
+	return "B"
+}
+
+func handleIndex() string {
+	return "index"
+}
+
+type formatter struct{}
+
+func (f formatter) upper(s string) string {
+	return strings.ToUpper(s)
+}
+
+func untested() string {
+	return "untested"
+}
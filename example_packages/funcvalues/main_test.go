@@ -0,0 +1,46 @@
+package funcvalues
+
+import (
+	"testing"
+)
+
+func run(t *testing.T, fn func(string) int) {
+	t.Helper()
+	fn("x")
+}
+
+func TestParse(t *testing.T) {
+	run(t, Parse)
+}
+
+func TestHandler(t *testing.T) {
+	h := handler(handleIndex)
+	h()
+}
+
+func TestTable(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func() string
+	}{
+		{name: "a", fn: A},
+		{name: "b", fn: B},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.fn()
+		})
+	}
+}
+
+func TestFormatter(t *testing.T) {
+	f := formatter{}
+	transforms := map[string]func(string) string{
+		"upper": f.upper,
+	}
+
+	for _, transform := range transforms {
+		transform("x")
+	}
+}
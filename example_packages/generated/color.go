@@ -0,0 +1,11 @@
+package generated
+
+//go:generate stringer -type=Color
+
+type Color int
+
+const (
+	Red Color = iota
+	Green
+	Blue
+)
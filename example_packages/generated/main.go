@@ -0,0 +1,7 @@
+package generated
+
+func Handwritten() string {
+	return Color(0).String()
+}
+
+func Untested() {}
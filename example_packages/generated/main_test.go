@@ -0,0 +1,7 @@
+package generated
+
+import "testing"
+
+func TestHandwritten(t *testing.T) {
+	Handwritten()
+}
@@ -0,0 +1,44 @@
+package generics
+
+type Stack[T any] struct {
+	items []T
+}
+
+func NewStack[T any]() *Stack[T] {
+	return &Stack[T]{}
+}
+
+func (s *Stack[T]) Push(v T) {
+	s.items = append(s.items, v)
+}
+
+func (s *Stack[T]) Pop() T {
+	v := s.items[len(s.items)-1]
+	s.items = s.items[:len(s.items)-1]
+	return v
+}
+
+func (s Stack[T]) Len() int {
+	return len(s.items)
+}
+
+type Pair[K comparable, V any] struct {
+	key   K
+	value V
+}
+
+func (p Pair[K, V]) Key() K {
+	return p.key
+}
+
+func (p *Pair[K, V]) Value() V {
+	return p.value
+}
+
+func Map[T, U any](in []T, f func(T) U) []U {
+	out := make([]U, 0, len(in))
+	for _, v := range in {
+		out = append(out, f(v))
+	}
+	return out
+}
@@ -0,0 +1,26 @@
+package generics
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestPush(t *testing.T) {
+	s := Stack[int]{}
+	s.Push(1)
+}
+
+func TestPop(t *testing.T) {
+	s := NewStack[string]()
+	s.Push("a")
+	s.Pop()
+}
+
+func TestValue(t *testing.T) {
+	p := &Pair[string, int]{}
+	p.Value()
+}
+
+func TestMap(t *testing.T) {
+	Map[int, string]([]int{1}, strconv.Itoa)
+}
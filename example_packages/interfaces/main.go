@@ -0,0 +1,30 @@
+package interfaces
+
+type Store interface {
+	Get(key string) string
+	Set(key, value string)
+}
+
+type memStore struct {
+	data map[string]string
+}
+
+func (m *memStore) Get(key string) string {
+	return m.data[key]
+}
+
+func (m *memStore) Set(key, value string) {
+	m.data[key] = value
+}
+
+type diskStore struct{}
+
+func (d diskStore) Get(key string) string {
+	return ""
+}
+
+func (d diskStore) Set(key, value string) {}
+
+func newMemStore() *memStore {
+	return &memStore{data: map[string]string{}}
+}
@@ -0,0 +1,21 @@
+package interfaces
+
+import (
+	"testing"
+)
+
+func TestMemStore(t *testing.T) {
+	var s Store = newMemStore()
+	s.Set("key", "value")
+	s.Get("key")
+}
+
+func TestDiskStore(t *testing.T) {
+	s := Store(diskStore{})
+	s.Get("key")
+}
+
+func TestDirectSet(t *testing.T) {
+	d := diskStore{}
+	d.Set("key", "value")
+}
@@ -0,0 +1,23 @@
+package nested
+
+type store struct{}
+
+func (s *store) Save() error {
+	return nil
+}
+
+func (s *store) Load() error {
+	return nil
+}
+
+func (s *store) Delete() error {
+	return nil
+}
+
+type deps struct {
+	store *store
+}
+
+type server struct {
+	deps deps
+}
@@ -0,0 +1,22 @@
+package nested
+
+import (
+	"testing"
+)
+
+type suite struct {
+	srv      *server
+	fixtures struct {
+		extra store
+	}
+}
+
+func TestSave(t *testing.T) {
+	s := suite{srv: &server{deps: deps{store: &store{}}}}
+	s.srv.deps.store.Save()
+}
+
+func TestLoad(t *testing.T) {
+	var s suite
+	s.fixtures.extra.Load()
+}
@@ -0,0 +1,5 @@
+//go:build extra
+
+package platforms
+
+func extraFeature() {}
@@ -0,0 +1,11 @@
+//go:build extra
+
+package platforms
+
+import (
+	"testing"
+)
+
+func TestExtraFeature(t *testing.T) {
+	extraFeature()
+}
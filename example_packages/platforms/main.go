@@ -0,0 +1,5 @@
+package platforms
+
+func Shared() string {
+	return "shared"
+}
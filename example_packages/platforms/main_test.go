@@ -0,0 +1,9 @@
+package platforms
+
+import (
+	"testing"
+)
+
+func TestShared(t *testing.T) {
+	Shared()
+}
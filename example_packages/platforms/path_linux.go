@@ -0,0 +1,5 @@
+package platforms
+
+func pathSeparator() string {
+	return "/"
+}
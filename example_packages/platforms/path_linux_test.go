@@ -0,0 +1,9 @@
+package platforms
+
+import (
+	"testing"
+)
+
+func TestPathSeparator(t *testing.T) {
+	pathSeparator()
+}
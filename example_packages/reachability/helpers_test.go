@@ -0,0 +1,22 @@
+package reachability
+
+import (
+	"testing"
+)
+
+func setup(t *testing.T) {
+	t.Helper()
+	ViaHelper()
+	teardown(1)
+}
+
+func teardown(depth int) {
+	ViaNestedHelper()
+	if depth > 0 {
+		teardown(depth - 1)
+	}
+}
+
+func unusedHelper() {
+	Unreached()
+}
@@ -0,0 +1,19 @@
+package reachability
+
+func Direct() {}
+
+func ViaHelper() {}
+
+func ViaNestedHelper() {}
+
+func Unreached() {}
+
+func Benchmarked() {}
+
+func Exampled() {}
+
+func Fuzzed() {}
+
+func FromMain() {}
+
+func NotATest() {}
@@ -0,0 +1,40 @@
+package reachability
+
+import (
+	"os"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	FromMain()
+	os.Exit(m.Run())
+}
+
+func TestDirect(t *testing.T) {
+	Direct()
+	setup(t)
+}
+
+func TestAlsoDirect(t *testing.T) {
+	setup(t)
+}
+
+func BenchmarkThing(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		Benchmarked()
+	}
+}
+
+func ExampleExampled() {
+	Exampled()
+}
+
+func FuzzThing(f *testing.F) {
+	f.Fuzz(func(t *testing.T, s string) {
+		Fuzzed()
+	})
+}
+
+func Testimony() {
+	NotATest()
+}
@@ -0,0 +1,15 @@
+package scope
+
+type Exported struct{}
+
+func (Exported) Method() {}
+
+func (Exported) method() {}
+
+type unexported struct{}
+
+func (unexported) Method() {}
+
+func Function() {}
+
+func function() {}
@@ -0,0 +1,15 @@
+package scope
+
+import "testing"
+
+func TestMethod(t *testing.T) {
+	e := Exported{}
+	e.Method()
+
+	u := unexported{}
+	u.Method()
+}
+
+func TestFunction(t *testing.T) {
+	function()
+}
@@ -0,0 +1,129 @@
+package statements
+
+// Every function in this package is called from one particular kind of
+// statement or expression in main_test.go, so that each form the analyzer
+// has to walk is covered by the example corpus.
+
+func ifInit() bool {
+	return true
+}
+
+func ifCond() bool {
+	return true
+}
+
+func elseBranch() {}
+
+func elseIfCond() bool {
+	return false
+}
+
+func forInit() int {
+	return 0
+}
+
+func forCond() int {
+	return 1
+}
+
+func forPost() int {
+	return 1
+}
+
+func rangeExpr() []int {
+	return []int{1}
+}
+
+func switchInit() int {
+	return 0
+}
+
+func switchTag() int {
+	return 0
+}
+
+func caseExpr() int {
+	return 0
+}
+
+func caseBody() {}
+
+func typeSwitchInit() {}
+
+func typeSwitchAssign() interface{} {
+	return 0
+}
+
+func labeled() {}
+
+func block() {}
+
+func deferArg() int {
+	return 0
+}
+
+func goArg() int {
+	return 0
+}
+
+func incDec() int {
+	return 0
+}
+
+func sendChan() chan int {
+	return make(chan int, 1)
+}
+
+func sendValue() int {
+	return 0
+}
+
+func selectComm() chan int {
+	c := make(chan int, 1)
+	c <- 0
+	return c
+}
+
+func receive() chan int {
+	c := make(chan int, 1)
+	c <- 0
+	return c
+}
+
+func indexLHS() int {
+	return 0
+}
+
+func binaryExpr() int {
+	return 0
+}
+
+func unaryExpr() int {
+	return 0
+}
+
+func chained() *chainable {
+	return &chainable{}
+}
+
+func declValue() int {
+	return 0
+}
+
+func multipleDeclSpecs() int {
+	return 0
+}
+
+func returnValue() int {
+	return 0
+}
+
+func funcIndex() int {
+	return 0
+}
+
+type chainable struct{}
+
+func (c *chainable) method() {}
+
+func sink(...interface{}) {}
@@ -0,0 +1,98 @@
+package statements
+
+import (
+	"testing"
+)
+
+func TestIf(t *testing.T) {
+	if ok := ifInit(); ok && ifCond() {
+		sink()
+	} else if elseIfCond() {
+		sink()
+	} else {
+		elseBranch()
+	}
+}
+
+func TestFor(t *testing.T) {
+	for i := forInit(); i < forCond(); i += forPost() {
+		sink(i)
+	}
+
+	for range rangeExpr() {
+		sink()
+	}
+}
+
+func TestSwitch(t *testing.T) {
+	switch x := switchInit(); switchTag() {
+	case caseExpr():
+		caseBody()
+	default:
+		sink(x)
+	}
+
+	switch typeSwitchInit(); v := typeSwitchAssign().(type) {
+	default:
+		sink(v)
+	}
+}
+
+func TestLabeledAndBlock(t *testing.T) {
+outer:
+	for {
+		labeled()
+		break outer
+	}
+
+	{
+		block()
+	}
+}
+
+func TestDeferAndGo(t *testing.T) {
+	defer sink(deferArg())
+	done := make(chan struct{})
+	go func(int) {
+		close(done)
+	}(goArg())
+	<-done
+}
+
+func TestIncDec(t *testing.T) {
+	counts := map[int]int{}
+	counts[incDec()]++
+	counts[indexLHS()] = 1
+}
+
+func TestChannels(t *testing.T) {
+	sendChan() <- sendValue()
+
+	select {
+	case v := <-selectComm():
+		sink(v)
+	}
+
+	<-receive()
+}
+
+func TestExpressions(t *testing.T) {
+	x := 1 + binaryExpr()
+	y := -unaryExpr()
+	chained().method()
+
+	var (
+		z = declValue()
+		w = multipleDeclSpecs()
+	)
+	sink(x, y, z, w)
+
+	fns := []func(...interface{}){sink}
+	fns[funcIndex()]()
+}
+
+func TestReturn(t *testing.T) {
+	func() int {
+		return returnValue()
+	}()
+}
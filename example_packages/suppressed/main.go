@@ -0,0 +1,14 @@
+package suppressed
+
+func Tested() {}
+
+//blanket:ignore trivial wrapper
+func Ignored() {}
+
+//blanket:ignore flaky on CI until=2020-01-01
+func Expired() {}
+
+// Later doesn't have a test yet.
+//
+//blanket:ignore waiting on the new API until=2999-12-31
+func Later() {}
@@ -0,0 +1,7 @@
+package suppressed
+
+import "testing"
+
+func TestTested(t *testing.T) {
+	Tested()
+}
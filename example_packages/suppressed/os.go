@@ -0,0 +1,10 @@
+//blanket:ignore-file thin wrappers around the os package
+
+package suppressed
+
+import "os"
+
+func hostname() string {
+	h, _ := os.Hostname()
+	return h
+}
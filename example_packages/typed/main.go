@@ -0,0 +1,33 @@
+package typed
+
+type store struct {
+	items map[string]string
+}
+
+func newStore() *store {
+	return &store{items: map[string]string{}}
+}
+
+func (s *store) get(k string) string {
+	return s.items[k]
+}
+
+func (s *store) set(k, v string) {
+	s.items[k] = v
+}
+
+type service struct {
+	db *store
+}
+
+func (s *service) lookup(k string) string {
+	return s.db.get(k)
+}
+
+func stores() []*store {
+	return []*store{newStore()}
+}
+
+func untested() string {
+	return "untested"
+}
@@ -0,0 +1,22 @@
+package typed
+
+import (
+	"testing"
+)
+
+func TestStore(t *testing.T) {
+	s := newStore()
+	s.set("a", "b")
+}
+
+func TestService(t *testing.T) {
+	svc := service{db: newStore()}
+	svc.db.get("a")
+	svc.lookup("a")
+}
+
+func TestStores(t *testing.T) {
+	for _, s := range stores() {
+		s.get("a")
+	}
+}
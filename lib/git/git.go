@@ -0,0 +1,212 @@
+// Package git reads what blanket needs from the local git repository: the lines a change touches, and the
+// contents of other revisions.
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"math"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// hunkHeader matches the header of a hunk in a unified diff, i.e. `@@ -10,2 +12,3 @@ func A() {`.
+var hunkHeader = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,(\d+))? @@`)
+
+// LineRange is a range of lines in the new version of a file, from Start to End inclusive. A range with an End
+// before its Start marks lines that were deleted right before line Start, without adding any.
+type LineRange struct {
+	Start int
+	End   int
+}
+
+// overlaps reports whether the range touches anything from line start to line end, inclusive.
+func (r LineRange) overlaps(start, end int) bool {
+	if r.End < r.Start {
+		// a deletion only touches the lines around it if it happened between two of them.
+		return start < r.Start && r.Start <= end
+	}
+	return r.Start <= end && start <= r.End
+}
+
+// Changes holds the changed lines of every file a diff touches, keyed by absolute path with symlinks resolved.
+type Changes map[string][]LineRange
+
+// Touches reports whether any line from start to end (inclusive) of a given file changed.
+func (c Changes) Touches(filename string, start, end int) bool {
+	if resolved, err := filepath.EvalSymlinks(filename); err == nil {
+		filename = resolved
+	}
+	if abs, err := filepath.Abs(filename); err == nil {
+		filename = abs
+	}
+
+	for _, r := range c[filename] {
+		if r.overlaps(start, end) {
+			return true
+		}
+	}
+	return false
+}
+
+// ChangedSince returns the lines of Go files in the repository a given directory is in that changed since a
+// given revision, including the changes that haven't been committed yet. Every line of the Go files git doesn't
+// track yet counts as changed, unless they're ignored.
+func ChangedSince(dir, rev string) (Changes, error) {
+	changes, err := diff(dir, rev)
+	if err != nil {
+		return nil, err
+	}
+
+	// git diff leaves out the files that haven't been added yet.
+	files, err := untracked(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, filename := range files {
+		changes[filename] = []LineRange{{Start: 1, End: math.MaxInt32}}
+	}
+	return changes, nil
+}
+
+// Staged returns the lines of Go files in the repository a given directory is in that are staged for commit.
+func Staged(dir string) (Changes, error) {
+	return diff(dir, "--cached")
+}
+
+// diff runs git diff with the provided arguments in a given directory, and parses its output.
+func diff(dir string, args ...string) (Changes, error) {
+	root, err := Root(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	// the prefixes are spelled out since diff.noprefix and diff.mnemonicPrefix change them otherwise.
+	args = append([]string{"diff", "--no-color", "--no-ext-diff", "--unified=0", "--src-prefix=a/", "--dst-prefix=b/"}, args...)
+	out, err := run(dir, append(args, "--", "*.go")...)
+	if err != nil {
+		return nil, err
+	}
+	return parseDiff(root, out)
+}
+
+// untracked returns the absolute paths of the Go files in the repository a given directory is in that git doesn't
+// track yet, leaving out the ones it ignores.
+func untracked(dir string) ([]string, error) {
+	root, err := Root(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := run(root, "ls-files", "--others", "--exclude-standard", "-z", "--", "*.go")
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, name := range strings.Split(out, "\x00") {
+		if name != "" {
+			files = append(files, filepath.Join(root, filepath.FromSlash(name)))
+		}
+	}
+	return files, nil
+}
+
+// run runs git with the provided arguments in a given directory, and returns what it writes to stdout.
+func run(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("running git %s: %s", args[0], strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+// parseDiff parses the output of `git diff --unified=0 --dst-prefix=b/`, whose paths are relative to a given root.
+// A diff that isn't empty but doesn't name any file is an error, since it means the prefixes weren't the expected ones.
+func parseDiff(root, in string) (Changes, error) {
+	changes := Changes{}
+	var current string
+	var files int
+
+	scanner := bufio.NewScanner(strings.NewReader(in))
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "+++ "):
+			current = ""
+			// deleted files show up as `+++ /dev/null`, and don't have any lines left to touch.
+			name := strings.TrimPrefix(line, "+++ ")
+			if name == "/dev/null" {
+				files++
+			} else if strings.HasPrefix(name, "b/") {
+				files++
+				current = filepath.Join(root, filepath.FromSlash(strings.TrimPrefix(name, "b/")))
+				changes[current] = changes[current]
+			}
+		case strings.HasPrefix(line, "@@ "):
+			if current == "" {
+				continue
+			}
+
+			match := hunkHeader.FindStringSubmatch(line)
+			if match == nil {
+				return nil, fmt.Errorf("invalid hunk header %q", line)
+			}
+			start, _ := strconv.Atoi(match[1])
+			count := 1
+			if match[2] != "" {
+				count, _ = strconv.Atoi(match[2])
+			}
+
+			if count == 0 {
+				// for deletions, the start is the line before the deleted ones.
+				changes[current] = append(changes[current], LineRange{Start: start + 1, End: start})
+			} else {
+				changes[current] = append(changes[current], LineRange{Start: start, End: start + count - 1})
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if files == 0 && strings.TrimSpace(in) != "" {
+		return nil, fmt.Errorf("couldn't find any files in the output of git diff")
+	}
+	return changes, nil
+}
+
+// Root returns the root of the working tree a given directory is in, with symlinks resolved.
+func Root(dir string) (string, error) {
+	out, err := run(dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", err
+	}
+
+	root := strings.TrimSpace(out)
+	if resolved, err := filepath.EvalSymlinks(root); err == nil {
+		root = resolved
+	}
+	return root, nil
+}
+
+// AddWorktree checks a given revision of the repository a given directory is in out into a new, detached
+// worktree at a given path.
+func AddWorktree(dir, rev, path string) error {
+	_, err := run(dir, "worktree", "add", "--detach", "--quiet", path, rev)
+	return err
+}
+
+// RemoveWorktree removes a worktree created by AddWorktree.
+func RemoveWorktree(dir, path string) error {
+	_, err := run(dir, "worktree", "remove", "--force", path)
+	return err
+}
@@ -0,0 +1,324 @@
+package git
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+////////////////////////////////////////////////////////
+//                                                    //
+//               Test Helper Functions                //
+//                                                    //
+////////////////////////////////////////////////////////
+
+func runGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Logf("failing because git %v returned error: %v\n%s", args, err, out)
+		t.FailNow()
+	}
+}
+
+func writeFile(t *testing.T, path, contents string) {
+	t.Helper()
+	if err := ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Logf("failing because WriteFile returned error: %v", err)
+		t.FailNow()
+	}
+}
+
+// buildExampleRepository returns the working tree of a new repository with a single commit of originalCode.
+func buildExampleRepository(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "example.go"), originalCode)
+	runGit(t, dir, "init", "-q")
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "-qm", "initial")
+	return dir
+}
+
+const originalCode = `package example
+
+func A() {
+	println("A")
+}
+
+func B() {
+	println("B")
+	println("B")
+}
+`
+
+////////////////////////////////////////////////////////
+//                                                    //
+//                   Actual Tests                     //
+//                                                    //
+////////////////////////////////////////////////////////
+
+func TestLineRangeOverlaps(t *testing.T) {
+	tests := []struct {
+		r          LineRange
+		start, end int
+		expected   bool
+	}{
+		{LineRange{Start: 3, End: 5}, 5, 9, true},
+		{LineRange{Start: 3, End: 5}, 6, 9, false},
+		{LineRange{Start: 3, End: 5}, 1, 3, true},
+		{LineRange{Start: 7, End: 6}, 5, 9, true},
+		{LineRange{Start: 10, End: 9}, 5, 9, false},
+		{LineRange{Start: 5, End: 4}, 5, 9, false},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, test.r.overlaps(test.start, test.end), "unexpected result for %+v against %d-%d", test.r, test.start, test.end)
+	}
+
+	t.Run("deletion inside of a function", func(_t *testing.T) {
+		deletion := LineRange{Start: 4, End: 3}
+		assert.True(t, deletion.overlaps(3, 5))
+	})
+}
+
+func TestParseDiff(t *testing.T) {
+	root := filepath.FromSlash("/src/project")
+	input := `diff --git a/api/server.go b/api/server.go
+index 1111111..2222222 100644
+--- a/api/server.go
++++ b/api/server.go
+@@ -10,2 +10,3 @@ func Start() {
+@@ -20 +21 @@ func handle() {
+@@ -30,2 +30,0 @@ func stop() {
+diff --git a/old.go b/old.go
+deleted file mode 100644
+--- a/old.go
++++ /dev/null
+@@ -1,3 +0,0 @@
+diff --git a/new.go b/new.go
+new file mode 100644
+--- /dev/null
++++ b/new.go
+@@ -0,0 +1,4 @@
+`
+
+	expected := Changes{
+		filepath.Join(root, "api", "server.go"): {{Start: 10, End: 12}, {Start: 21, End: 21}, {Start: 31, End: 30}},
+		filepath.Join(root, "new.go"):           {{Start: 1, End: 4}},
+	}
+	actual, err := parseDiff(root, input)
+
+	assert.NoError(t, err)
+	assert.Equal(t, expected, actual)
+
+	_, err = parseDiff(root, "+++ b/a.go\n@@ nonsense @@\n")
+	assert.Error(t, err)
+
+	_, err = parseDiff(root, "--- api/server.go\n+++ api/server.go\n@@ -10,2 +10,3 @@\n")
+	assert.Error(t, err, "a diff without the expected prefixes shouldn't pass for an empty one")
+
+	actual, err = parseDiff(root, "")
+	assert.NoError(t, err)
+	assert.Empty(t, actual)
+}
+
+func TestChangedSince(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "example.go")
+	writeFile(t, filename, originalCode)
+	runGit(t, dir, "init", "-q")
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "-qm", "initial")
+
+	writeFile(t, filename, originalCode+`
+func C() {}
+`)
+	writeFile(t, filepath.Join(dir, "notes.txt"), "not go code\n")
+	untrackedFilename := filepath.Join(dir, "untracked.go")
+	writeFile(t, untrackedFilename, "package example\n\nfunc D() {}\n")
+	writeFile(t, filepath.Join(dir, "ignored.go"), "package example\n\nfunc E() {}\n")
+	writeFile(t, filepath.Join(dir, ".gitignore"), "ignored.go\n")
+
+	t.Run("working tree", func(_t *testing.T) {
+		actual, err := ChangedSince(dir, "HEAD")
+
+		assert.NoError(t, err)
+		assert.Len(t, actual, 2)
+		assert.False(t, actual.Touches(filename, 3, 5), "A didn't change")
+		assert.False(t, actual.Touches(filename, 7, 10), "B didn't change")
+		assert.True(t, actual.Touches(filename, 12, 12), "C is new")
+		assert.True(t, actual.Touches(untrackedFilename, 3, 3), "D is in a file git doesn't track yet")
+	})
+
+	t.Run("staged", func(_t *testing.T) {
+		actual, err := Staged(dir)
+		assert.NoError(t, err)
+		assert.Empty(t, actual)
+
+		runGit(t, dir, "add", "example.go")
+		actual, err = Staged(dir)
+		assert.NoError(t, err)
+		assert.True(t, actual.Touches(filename, 12, 12))
+	})
+
+	t.Run("with prefixes turned off", func(_t *testing.T) {
+		runGit(t, dir, "config", "diff.noprefix", "true")
+		runGit(t, dir, "config", "diff.mnemonicPrefix", "true")
+		defer runGit(t, dir, "config", "--unset", "diff.noprefix")
+		defer runGit(t, dir, "config", "--unset", "diff.mnemonicPrefix")
+
+		actual, err := ChangedSince(dir, "HEAD")
+
+		assert.NoError(t, err)
+		assert.True(t, actual.Touches(filename, 12, 12), "C is new")
+	})
+
+	t.Run("invalid revision", func(_t *testing.T) {
+		_, err := ChangedSince(dir, "nonexistent")
+		assert.Error(t, err)
+	})
+
+	t.Run("outside of a repository", func(_t *testing.T) {
+		_, err := ChangedSince(t.TempDir(), "HEAD")
+		assert.Error(t, err)
+	})
+}
+
+func TestUntracked(t *testing.T) {
+	dir := buildExampleRepository(t)
+	writeFile(t, filepath.Join(dir, "new file.go"), "package example\n")
+	writeFile(t, filepath.Join(dir, "notes.txt"), "not go code\n")
+	writeFile(t, filepath.Join(dir, "ignored.go"), "package example\n")
+	writeFile(t, filepath.Join(dir, ".gitignore"), "ignored.go\n")
+	root, err := Root(dir)
+	if err != nil {
+		t.Logf("failing because Root returned error: %v", err)
+		t.FailNow()
+	}
+
+	t.Run("normal", func(_t *testing.T) {
+		actual, err := untracked(dir)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{filepath.Join(root, "new file.go")}, actual)
+	})
+
+	t.Run("outside of a repository", func(_t *testing.T) {
+		_, err := untracked(t.TempDir())
+		assert.Error(t, err)
+	})
+}
+
+func TestDiff(t *testing.T) {
+	dir := buildExampleRepository(t)
+	filename := filepath.Join(dir, "example.go")
+	writeFile(t, filename, originalCode+"\nfunc C() {}\n")
+
+	t.Run("normal", func(_t *testing.T) {
+		actual, err := diff(dir, "HEAD")
+
+		assert.NoError(t, err)
+		assert.True(t, actual.Touches(filename, 12, 12))
+		assert.False(t, actual.Touches(filename, 3, 5))
+	})
+
+	t.Run("with invalid arguments", func(_t *testing.T) {
+		_, err := diff(dir, "--no-such-flag")
+		assert.Error(t, err)
+	})
+}
+
+func TestRun(t *testing.T) {
+	dir := buildExampleRepository(t)
+
+	t.Run("normal", func(_t *testing.T) {
+		actual, err := run(dir, "ls-files")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "example.go\n", actual)
+	})
+
+	t.Run("with an error", func(_t *testing.T) {
+		_, err := run(dir, "rev-parse", "nonexistent")
+
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "running git rev-parse")
+		}
+	})
+}
+
+func TestRoot(t *testing.T) {
+	dir := buildExampleRepository(t)
+	expected, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		t.Logf("failing because EvalSymlinks returned error: %v", err)
+		t.FailNow()
+	}
+	subdirectory := filepath.Join(dir, "sub")
+	if err := os.Mkdir(subdirectory, os.ModePerm); err != nil {
+		t.Logf("failing because Mkdir returned error: %v", err)
+		t.FailNow()
+	}
+
+	t.Run("normal", func(_t *testing.T) {
+		actual, err := Root(subdirectory)
+
+		assert.NoError(t, err)
+		assert.Equal(t, expected, actual)
+	})
+
+	t.Run("outside of a repository", func(_t *testing.T) {
+		_, err := Root(t.TempDir())
+		assert.Error(t, err)
+	})
+}
+
+func TestAddWorktree(t *testing.T) {
+	dir := buildExampleRepository(t)
+	writeFile(t, filepath.Join(dir, "example.go"), originalCode+"\nfunc C() {}\n")
+	runGit(t, dir, "commit", "-qam", "add C")
+
+	t.Run("normal", func(_t *testing.T) {
+		path := filepath.Join(t.TempDir(), "worktree")
+
+		err := AddWorktree(dir, "HEAD~1", path)
+
+		assert.NoError(t, err)
+		actual, err := ioutil.ReadFile(filepath.Join(path, "example.go"))
+		assert.NoError(t, err)
+		assert.Equal(t, originalCode, string(actual), "expected the worktree to have the given revision checked out")
+	})
+
+	t.Run("nonexistent revision", func(_t *testing.T) {
+		err := AddWorktree(dir, "nonexistent", filepath.Join(t.TempDir(), "worktree"))
+		assert.Error(t, err)
+	})
+}
+
+func TestRemoveWorktree(t *testing.T) {
+	dir := buildExampleRepository(t)
+
+	t.Run("normal", func(_t *testing.T) {
+		path := filepath.Join(t.TempDir(), "worktree")
+		if err := AddWorktree(dir, "HEAD", path); err != nil {
+			t.Logf("failing because AddWorktree returned error: %v", err)
+			t.FailNow()
+		}
+
+		err := RemoveWorktree(dir, path)
+
+		assert.NoError(t, err)
+		_, err = os.Stat(path)
+		assert.True(t, os.IsNotExist(err), "expected the worktree to be gone")
+	})
+
+	t.Run("nonexistent worktree", func(_t *testing.T) {
+		err := RemoveWorktree(dir, filepath.Join(t.TempDir(), "worktree"))
+		assert.Error(t, err)
+	})
+}
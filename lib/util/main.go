@@ -3,6 +3,7 @@ package util
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -25,3 +26,20 @@ func BuildExampleFilePath(filename string) string {
 	gopath := os.Getenv("GOPATH")
 	return fmt.Sprintf("%s/src/gitlab.com/verygoodsoftwarenotvirus/blanket/example_files/%s", gopath, filename)
 }
+
+// WriteFiles writes a set of files, keyed by their path relative to a given directory, creating the directories
+// they're in along the way.
+func WriteFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, contents := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+			t.Logf("failing because MkdirAll returned error: %v", err)
+			t.FailNow()
+		}
+		if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+			t.Logf("failing because WriteFile returned error: %v", err)
+			t.FailNow()
+		}
+	}
+}
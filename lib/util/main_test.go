@@ -3,6 +3,7 @@ package util
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -25,3 +26,18 @@ func TestBuildExampleFilePath(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestWriteFiles(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	WriteFiles(t, dir, map[string]string{"a.go": "package a\n", "sub/b.go": "package b\n"})
+
+	for name, expected := range map[string]string{"a.go": "package a\n", filepath.Join("sub", "b.go"): "package b\n"} {
+		result, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil || string(result) != expected {
+			t.Logf("Expected '%s' in %s, got '%s' (%v)", expected, name, result, err)
+			t.Fail()
+		}
+	}
+}
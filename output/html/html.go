@@ -24,6 +24,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
 
 	"gitlab.com/verygoodsoftwarenotvirus/blanket/analysis"
@@ -32,9 +33,11 @@ import (
 )
 
 const (
-	blanketClassName = "blanket-uncovered"
-	blanketColor     = "rgb(252, 242, 106)"
-	tmplHTML         = `
+	blanketClassName    = "blanket-uncovered"
+	blanketColor        = "rgb(252, 242, 106)"
+	suppressedClassName = "blanket-suppressed"
+	suppressedColor     = "rgb(128, 128, 224)"
+	tmplHTML            = `
 <!DOCTYPE html>
 <html>
 	<head>
@@ -71,6 +74,10 @@ const (
 			#legend span {
 				margin: 0 1px;
 			}
+			#suppressed {
+				margin: 10px;
+				border-top: 1px solid rgb(80, 80, 80);
+			}
 			{{colors}}
 		</style>
 	</head>
@@ -102,6 +109,7 @@ const (
 				<span class="cov10">high coverage</span>
 {{end}}
 				<span class="blanket-uncovered">indirectly covered</span>
+				<span class="blanket-suppressed">suppressed</span>
 			</div>
 		</div>
 		<div id="content">
@@ -109,6 +117,14 @@ const (
 		<pre class="file" id="file{{$i}}" {{if $i}}style="display: none"{{end}}>{{$f.Body}}</pre>
 {{end}}
 		</div>
+{{- if .Suppressed}}
+		<div id="suppressed">
+			<pre>suppressed:</pre>
+{{range .Suppressed}}
+			<pre class="blanket-suppressed">{{.Name}} ({{.Filename}}:{{.DeclPos.Line}}){{with .Reason}}: {{.}}{{end}}{{if not .Until.IsZero}} until {{.Until.Format "2006-01-02"}}{{end}}</pre>
+{{end}}
+		</div>
+{{- end}}
 	</body>
 	<script>
 	(function() {
@@ -130,8 +146,9 @@ const (
 var htmlTemplate = template.Must(template.New("html").Funcs(template.FuncMap{"colors": cssColors}).Parse(tmplHTML))
 
 type templateData struct {
-	Files []*templateFile
-	Set   bool
+	Files      []*templateFile
+	Set        bool
+	Suppressed []analysis.SuppressedFunc
 }
 
 type templateFile struct {
@@ -160,6 +177,15 @@ func Output(profilePath, outfile string, report *analysis.BlanketReport) error {
 	}
 
 	var d templateData
+	for _, f := range report.Suppressed {
+		d.Suppressed = append(d.Suppressed, f)
+	}
+	sort.Slice(d.Suppressed, func(i, j int) bool {
+		if d.Suppressed[i].Filename != d.Suppressed[j].Filename {
+			return d.Suppressed[i].Filename < d.Suppressed[j].Filename
+		}
+		return d.Suppressed[i].DeclPos.Line < d.Suppressed[j].DeclPos.Line
+	})
 
 	for _, profile := range profiles {
 		fn := profile.FileName
@@ -242,6 +268,7 @@ func percentCovered(p *cover.Profile) float64 {
 func htmlGen(w io.Writer, src []byte, filename string, boundaries []cover.Boundary, report *analysis.BlanketReport) error {
 	dst := bufio.NewWriter(w)
 	var relevantFunc analysis.BlanketFunc
+	var relevantSuppression *analysis.Suppression
 
 	currentLine := 1
 	for i := range src {
@@ -250,20 +277,35 @@ func htmlGen(w io.Writer, src []byte, filename string, boundaries []cover.Bounda
 			if b.Start {
 				for _, d := range report.DeclaredDetails {
 					if strings.Contains(d.Filename, filename) {
-						if d.RBracePos.Line == currentLine {
+						if d.LBracePos.Line == currentLine {
 							relevantFunc = d
+							relevantSuppression = nil
 							break
 						}
 					}
 				}
+				for _, d := range report.Suppressed {
+					if strings.Contains(d.Filename, filename) && d.LBracePos.Line == currentLine {
+						suppression := d.Suppression
+						relevantFunc = d.BlanketFunc
+						relevantSuppression = &suppression
+						break
+					}
+				}
 				relevantFuncCalled := report.Called.Has(relevantFunc.Name)
 
 				n := 0
 				if b.Count > 0 {
 					n = int(math.Floor(b.Norm*9)) + 1
 				}
-				if relevantFunc.Name != "" && n > 0 && !relevantFuncCalled && currentLine <= relevantFunc.LBracePos.Line {
-					fmt.Fprintf(dst, `<span class="%s" title="%v">`, blanketClassName, b.Count)
+				if relevantSuppression != nil && n > 0 && currentLine <= relevantFunc.RBracePos.Line {
+					fmt.Fprintf(dst, `<span class="%s" title="%v (suppressed: %s)">`, suppressedClassName, b.Count, template.HTMLEscapeString(relevantSuppression.Reason))
+				} else if relevantFunc.Name != "" && n > 0 && !relevantFuncCalled && currentLine <= relevantFunc.RBracePos.Line {
+					if depth, ok := report.CallDepths[relevantFunc.Name]; ok {
+						fmt.Fprintf(dst, `<span class="%s" title="%v (depth %d)">`, blanketClassName, b.Count, depth)
+					} else {
+						fmt.Fprintf(dst, `<span class="%s" title="%v">`, blanketClassName, b.Count)
+					}
 				} else {
 					fmt.Fprintf(dst, `<span class="cov%v" title="%v">`, n, b.Count)
 				}
@@ -333,5 +375,6 @@ func cssColors() template.CSS {
 		fmt.Fprintf(&buf, ".cov%v { color: %v }\n\t\t\t", i, rgb(i))
 	}
 	fmt.Fprint(&buf, fmt.Sprintf(".%s { color: %s }\n", blanketClassName, blanketColor))
+	fmt.Fprint(&buf, fmt.Sprintf("\t\t\t.%s { color: %s }\n", suppressedClassName, suppressedColor))
 	return template.CSS(buf.String())
 }
@@ -13,6 +13,7 @@ import (
 	"os/exec"
 	"runtime"
 	"testing"
+	"time"
 
 	"gitlab.com/verygoodsoftwarenotvirus/blanket/analysis"
 	"gitlab.com/verygoodsoftwarenotvirus/blanket/lib/util"
@@ -62,29 +63,29 @@ func TestHTMLOutput(t *testing.T) {
 				Name:      "a",
 				Filename:  simpleMainPath,
 				DeclPos:   token.Position{Filename: simpleMainPath, Offset: 16, Line: 3, Column: 1},
-				RBracePos: token.Position{Filename: simpleMainPath, Offset: 32, Line: 3, Column: 17},
-				LBracePos: token.Position{Filename: simpleMainPath, Offset: 46, Line: 5, Column: 1},
+				LBracePos: token.Position{Filename: simpleMainPath, Offset: 32, Line: 3, Column: 17},
+				RBracePos: token.Position{Filename: simpleMainPath, Offset: 46, Line: 5, Column: 1},
 			},
 			"b": {
 				Name:      "b",
 				Filename:  simpleMainPath,
 				DeclPos:   token.Position{Filename: simpleMainPath, Offset: 49, Line: 7, Column: 1},
-				RBracePos: token.Position{Filename: simpleMainPath, Offset: 65, Line: 7, Column: 17},
-				LBracePos: token.Position{Filename: simpleMainPath, Offset: 79, Line: 9, Column: 1},
+				LBracePos: token.Position{Filename: simpleMainPath, Offset: 65, Line: 7, Column: 17},
+				RBracePos: token.Position{Filename: simpleMainPath, Offset: 79, Line: 9, Column: 1},
 			},
 			"c": {
 				Name:      "c",
 				Filename:  simpleMainPath,
 				DeclPos:   token.Position{Filename: simpleMainPath, Offset: 82, Line: 11, Column: 1},
-				RBracePos: token.Position{Filename: simpleMainPath, Offset: 98, Line: 11, Column: 17},
-				LBracePos: token.Position{Filename: simpleMainPath, Offset: 112, Line: 13, Column: 1},
+				LBracePos: token.Position{Filename: simpleMainPath, Offset: 98, Line: 11, Column: 17},
+				RBracePos: token.Position{Filename: simpleMainPath, Offset: 112, Line: 13, Column: 1},
 			},
 			"wrapper": {
 				Name:      "wrapper",
 				Filename:  simpleMainPath,
 				DeclPos:   token.Position{Filename: simpleMainPath, Offset: 115, Line: 15, Column: 1},
-				RBracePos: token.Position{Filename: simpleMainPath, Offset: 130, Line: 15, Column: 16},
-				LBracePos: token.Position{Filename: simpleMainPath, Offset: 147, Line: 19, Column: 1},
+				LBracePos: token.Position{Filename: simpleMainPath, Offset: 130, Line: 15, Column: 16},
+				RBracePos: token.Position{Filename: simpleMainPath, Offset: 147, Line: 19, Column: 1},
 			},
 		},
 	}
@@ -227,6 +228,10 @@ func TestHTMLOutput(t *testing.T) {
 			#legend span {
 				margin: 0 1px;
 			}
+			#suppressed {
+				margin: 10px;
+				border-top: 1px solid rgb(80, 80, 80);
+			}
 			.cov0 { color: rgb(192, 0, 0) }
 			.cov1 { color: rgb(128, 128, 128) }
 			.cov2 { color: rgb(116, 140, 131) }
@@ -239,6 +244,7 @@ func TestHTMLOutput(t *testing.T) {
 			.cov9 { color: rgb(32, 224, 152) }
 			.cov10 { color: rgb(20, 236, 155) }
 			.blanket-uncovered { color: rgb(252, 242, 106) }
+			.blanket-suppressed { color: rgb(128, 128, 224) }
 
 		</style>
 	</head>
@@ -267,6 +273,7 @@ func TestHTMLOutput(t *testing.T) {
 				<span class="cov10">high coverage</span>
 
 				<span class="blanket-uncovered">indirectly covered</span>
+				<span class="blanket-suppressed">suppressed</span>
 			</div>
 		</div>
 		<div id="content">
@@ -369,6 +376,10 @@ func wrapper() <span class="cov1" title="1">{
 			#legend span {
 				margin: 0 1px;
 			}
+			#suppressed {
+				margin: 10px;
+				border-top: 1px solid rgb(80, 80, 80);
+			}
 			.cov0 { color: rgb(192, 0, 0) }
 			.cov1 { color: rgb(128, 128, 128) }
 			.cov2 { color: rgb(116, 140, 131) }
@@ -381,6 +392,7 @@ func wrapper() <span class="cov1" title="1">{
 			.cov9 { color: rgb(32, 224, 152) }
 			.cov10 { color: rgb(20, 236, 155) }
 			.blanket-uncovered { color: rgb(252, 242, 106) }
+			.blanket-suppressed { color: rgb(128, 128, 224) }
 
 		</style>
 	</head>
@@ -400,6 +412,7 @@ func wrapper() <span class="cov1" title="1">{
 				<span class="cov8">covered</span>
 
 				<span class="blanket-uncovered">indirectly covered</span>
+				<span class="blanket-suppressed">suppressed</span>
 			</div>
 		</div>
 		<div id="content">
@@ -454,6 +467,32 @@ func wrapper() <span class="cov8" title="1">{
 			log.Printf(`Unable to delete file "%s", be sure to delete it.`, tmpFile)
 		}
 	})
+
+	t.Run("with suppressed functions", func(_t *testing.T) {
+		tmpFile := buildExampleFileAbsPath("temp.html")
+		report := &analysis.BlanketReport{
+			Called:          exampleReport.Called,
+			Declared:        exampleReport.Declared,
+			DeclaredDetails: exampleReport.DeclaredDetails,
+			Suppressed: map[string]analysis.SuppressedFunc{
+				"b": {
+					BlanketFunc: exampleReport.DeclaredDetails["b"],
+					Suppression: analysis.Suppression{Reason: "trivial", Until: time.Date(2027, 1, 1, 0, 0, 0, 0, time.Local)},
+				},
+			},
+		}
+
+		err := Output(simpleCountPath, tmpFile, report)
+		assert.Nil(t, err)
+
+		f, err := ioutil.ReadFile(tmpFile)
+		assert.Nil(t, err)
+		assert.Contains(t, string(f), `<pre class="blanket-suppressed">b (`+simpleMainPath+`:7): trivial until 2027-01-01</pre>`)
+
+		if err = os.Remove(tmpFile); err != nil {
+			log.Printf(`Unable to delete file "%s", be sure to delete it.`, tmpFile)
+		}
+	})
 }
 
 func TestHTMLGen(t *testing.T) {
@@ -472,13 +511,13 @@ func TestHTMLGen(t *testing.T) {
 						Line:     3,
 						Column:   1,
 					},
-					RBracePos: token.Position{
+					LBracePos: token.Position{
 						Filename: simpleMainPath,
 						Offset:   32,
 						Line:     3,
 						Column:   17,
 					},
-					LBracePos: token.Position{
+					RBracePos: token.Position{
 						Filename: simpleMainPath,
 						Offset:   46,
 						Line:     5,
@@ -494,13 +533,13 @@ func TestHTMLGen(t *testing.T) {
 						Line:     7,
 						Column:   1,
 					},
-					RBracePos: token.Position{
+					LBracePos: token.Position{
 						Filename: simpleMainPath,
 						Offset:   65,
 						Line:     7,
 						Column:   17,
 					},
-					LBracePos: token.Position{
+					RBracePos: token.Position{
 						Filename: simpleMainPath,
 						Offset:   79,
 						Line:     9,
@@ -516,13 +555,13 @@ func TestHTMLGen(t *testing.T) {
 						Line:     11,
 						Column:   1,
 					},
-					RBracePos: token.Position{
+					LBracePos: token.Position{
 						Filename: simpleMainPath,
 						Offset:   98,
 						Line:     11,
 						Column:   17,
 					},
-					LBracePos: token.Position{
+					RBracePos: token.Position{
 						Filename: simpleMainPath,
 						Offset:   112,
 						Line:     13,
@@ -538,13 +577,13 @@ func TestHTMLGen(t *testing.T) {
 						Line:     15,
 						Column:   1,
 					},
-					RBracePos: token.Position{
+					LBracePos: token.Position{
 						Filename: simpleMainPath,
 						Offset:   130,
 						Line:     15,
 						Column:   16,
 					},
-					LBracePos: token.Position{
+					RBracePos: token.Position{
 						Filename: simpleMainPath,
 						Offset:   147,
 						Line:     19,
@@ -594,6 +633,22 @@ func wrapper() <span class="cov1" title="1">{
 		actual := buf.String()
 
 		assert.Equal(t, expected, actual, "output should match expectation")
+
+		// functions the tests only reach indirectly say how far away from a test they are.
+		exampleReport.CallDepths = map[string]int{"b": 1}
+		buf.Reset()
+		err = htmlGen(&buf, src, simpleMainPath, profiles[0].Boundaries(src), exampleReport)
+		assert.Nil(t, err)
+		assert.Contains(t, buf.String(), `func b() string <span class="blanket-uncovered" title="1 (depth 1)">{`)
+
+		// suppressed functions are marked as such, whether they're called or not.
+		exampleReport.Suppressed = map[string]analysis.SuppressedFunc{
+			"b": {BlanketFunc: exampleReport.DeclaredDetails["b"], Suppression: analysis.Suppression{Reason: "trivial"}},
+		}
+		buf.Reset()
+		err = htmlGen(&buf, src, simpleMainPath, profiles[0].Boundaries(src), exampleReport)
+		assert.Nil(t, err)
+		assert.Contains(t, buf.String(), `func b() string <span class="blanket-suppressed" title="1 (suppressed: trivial)">{`)
 	})
 
 	t.Run("with conditionals", func(_t *testing.T) {
@@ -611,13 +666,13 @@ func wrapper() <span class="cov1" title="1">{
 						Line:     3,
 						Column:   1,
 					},
-					RBracePos: token.Position{
+					LBracePos: token.Position{
 						Filename: simpleMainPath,
 						Offset:   32,
 						Line:     3,
 						Column:   17,
 					},
-					LBracePos: token.Position{
+					RBracePos: token.Position{
 						Filename: simpleMainPath,
 						Offset:   46,
 						Line:     8,
@@ -633,13 +688,13 @@ func wrapper() <span class="cov1" title="1">{
 						Line:     10,
 						Column:   1,
 					},
-					RBracePos: token.Position{
+					LBracePos: token.Position{
 						Filename: simpleMainPath,
 						Offset:   65,
 						Line:     10,
 						Column:   17,
 					},
-					LBracePos: token.Position{
+					RBracePos: token.Position{
 						Filename: simpleMainPath,
 						Offset:   79,
 						Line:     12,
@@ -655,13 +710,13 @@ func wrapper() <span class="cov1" title="1">{
 						Line:     14,
 						Column:   1,
 					},
-					RBracePos: token.Position{
+					LBracePos: token.Position{
 						Filename: simpleMainPath,
 						Offset:   98,
 						Line:     14,
 						Column:   17,
 					},
-					LBracePos: token.Position{
+					RBracePos: token.Position{
 						Filename: simpleMainPath,
 						Offset:   112,
 						Line:     16,
@@ -677,13 +732,13 @@ func wrapper() <span class="cov1" title="1">{
 						Line:     18,
 						Column:   1,
 					},
-					RBracePos: token.Position{
+					LBracePos: token.Position{
 						Filename: simpleMainPath,
 						Offset:   130,
 						Line:     18,
 						Column:   16,
 					},
-					LBracePos: token.Position{
+					RBracePos: token.Position{
 						Filename: simpleMainPath,
 						Offset:   147,
 						Line:     22,
@@ -753,13 +808,13 @@ func wrapper() <span class="cov8" title="1">{
 						Line:     3,
 						Column:   1,
 					},
-					RBracePos: token.Position{
+					LBracePos: token.Position{
 						Filename: simpleMainPath,
 						Offset:   46,
 						Line:     3,
 						Column:   31,
 					},
-					LBracePos: token.Position{
+					RBracePos: token.Position{
 						Filename: simpleMainPath,
 						Offset:   92,
 						Line:     8,
@@ -775,13 +830,13 @@ func wrapper() <span class="cov8" title="1">{
 						Line:     10,
 						Column:   1,
 					},
-					RBracePos: token.Position{
+					LBracePos: token.Position{
 						Filename: simpleMainPath,
 						Offset:   111,
 						Line:     10,
 						Column:   17,
 					},
-					LBracePos: token.Position{
+					RBracePos: token.Position{
 						Filename: simpleMainPath,
 						Offset:   125,
 						Line:     12,
@@ -797,13 +852,13 @@ func wrapper() <span class="cov8" title="1">{
 						Line:     14,
 						Column:   1,
 					},
-					RBracePos: token.Position{
+					LBracePos: token.Position{
 						Filename: simpleMainPath,
 						Offset:   144,
 						Line:     14,
 						Column:   17,
 					},
-					LBracePos: token.Position{
+					RBracePos: token.Position{
 						Filename: simpleMainPath,
 						Offset:   158,
 						Line:     16,
@@ -819,13 +874,13 @@ func wrapper() <span class="cov8" title="1">{
 						Line:     18,
 						Column:   1,
 					},
-					RBracePos: token.Position{
+					LBracePos: token.Position{
 						Filename: simpleMainPath,
 						Offset:   190,
 						Line:     18,
 						Column:   30,
 					},
-					LBracePos: token.Position{
+					RBracePos: token.Position{
 						Filename: simpleMainPath,
 						Offset:   216,
 						Line:     22,
@@ -974,7 +1029,7 @@ func TestRGB(t *testing.T) {
 }
 
 func TestCSSColors(t *testing.T) {
-	expected := template.CSS(".cov0 { color: rgb(192, 0, 0) }\n\t\t\t.cov1 { color: rgb(128, 128, 128) }\n\t\t\t.cov2 { color: rgb(116, 140, 131) }\n\t\t\t.cov3 { color: rgb(104, 152, 134) }\n\t\t\t.cov4 { color: rgb(92, 164, 137) }\n\t\t\t.cov5 { color: rgb(80, 176, 140) }\n\t\t\t.cov6 { color: rgb(68, 188, 143) }\n\t\t\t.cov7 { color: rgb(56, 200, 146) }\n\t\t\t.cov8 { color: rgb(44, 212, 149) }\n\t\t\t.cov9 { color: rgb(32, 224, 152) }\n\t\t\t.cov10 { color: rgb(20, 236, 155) }\n\t\t\t.blanket-uncovered { color: rgb(252, 242, 106) }\n")
+	expected := template.CSS(".cov0 { color: rgb(192, 0, 0) }\n\t\t\t.cov1 { color: rgb(128, 128, 128) }\n\t\t\t.cov2 { color: rgb(116, 140, 131) }\n\t\t\t.cov3 { color: rgb(104, 152, 134) }\n\t\t\t.cov4 { color: rgb(92, 164, 137) }\n\t\t\t.cov5 { color: rgb(80, 176, 140) }\n\t\t\t.cov6 { color: rgb(68, 188, 143) }\n\t\t\t.cov7 { color: rgb(56, 200, 146) }\n\t\t\t.cov8 { color: rgb(44, 212, 149) }\n\t\t\t.cov9 { color: rgb(32, 224, 152) }\n\t\t\t.cov10 { color: rgb(20, 236, 155) }\n\t\t\t.blanket-uncovered { color: rgb(252, 242, 106) }\n\t\t\t.blanket-suppressed { color: rgb(128, 128, 224) }\n")
 	actual := cssColors()
 	assert.Equal(t, expected, actual, "CSSColors should return expected output")
 }
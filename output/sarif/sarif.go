@@ -0,0 +1,235 @@
+// Package sarif renders the functions without direct unit tests as a SARIF 2.1.0 log, which code scanning
+// dashboards can import alongside the results of other static analysis tools.
+package sarif
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gitlab.com/verygoodsoftwarenotvirus/blanket/analysis"
+)
+
+const (
+	schemaURI      = "https://json.schemastore.org/sarif-2.1.0.json"
+	version        = "2.1.0"
+	informationURI = "https://gitlab.com/verygoodsoftwarenotvirus/blanket"
+
+	// RuleID identifies the results for functions without a direct unit test.
+	RuleID = "untested-function"
+	// srcRoot is the base the locations of results are relative to, which points at the analyzed project.
+	srcRoot = "SRCROOT"
+	// fingerprintKey names the fingerprint of results. Its version changes whenever the fingerprint does.
+	fingerprintKey = "blanketFunction/v1"
+)
+
+// Log is the top level object of a SARIF file.
+type Log struct {
+	Schema  string `json:"$schema"`
+	Version string `json:"version"`
+	Runs    []Run  `json:"runs"`
+}
+
+// Run is a single invocation of an analysis tool, along with its results.
+type Run struct {
+	Tool               Tool                        `json:"tool"`
+	OriginalURIBaseIDs map[string]ArtifactLocation `json:"originalUriBaseIds,omitempty"`
+	Results            []Result                    `json:"results"`
+}
+
+// Tool describes the analysis tool that produced a run.
+type Tool struct {
+	Driver Driver `json:"driver"`
+}
+
+// Driver is the component of a tool that produced a run, along with the rules it checks.
+type Driver struct {
+	Name           string `json:"name"`
+	Version        string `json:"version,omitempty"`
+	InformationURI string `json:"informationUri,omitempty"`
+	Rules          []Rule `json:"rules"`
+}
+
+// Rule describes a single kind of result.
+type Rule struct {
+	ID                   string              `json:"id"`
+	Name                 string              `json:"name"`
+	ShortDescription     Message             `json:"shortDescription"`
+	FullDescription      Message             `json:"fullDescription"`
+	HelpURI              string              `json:"helpUri,omitempty"`
+	DefaultConfiguration RuleConfiguration   `json:"defaultConfiguration"`
+	Properties           map[string][]string `json:"properties,omitempty"`
+}
+
+// RuleConfiguration holds the level results of a rule are reported at by default.
+type RuleConfiguration struct {
+	Level string `json:"level"`
+}
+
+// Message is a plain text message.
+type Message struct {
+	Text string `json:"text"`
+}
+
+// Result is a single finding.
+type Result struct {
+	RuleID              string            `json:"ruleId"`
+	RuleIndex           int               `json:"ruleIndex"`
+	Level               string            `json:"level"`
+	Message             Message           `json:"message"`
+	Locations           []Location        `json:"locations"`
+	PartialFingerprints map[string]string `json:"partialFingerprints"`
+}
+
+// Location is where a result was found.
+type Location struct {
+	PhysicalLocation PhysicalLocation `json:"physicalLocation"`
+}
+
+// PhysicalLocation is a region of a file.
+type PhysicalLocation struct {
+	ArtifactLocation ArtifactLocation `json:"artifactLocation"`
+	Region           Region           `json:"region"`
+}
+
+// ArtifactLocation is the URI of a file, relative to the base URIBaseID stands for if it's set.
+type ArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+// Region is a range of a file, from StartLine and StartColumn up to (but not including) EndColumn on EndLine.
+type Region struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+	EndLine     int `json:"endLine,omitempty"`
+	EndColumn   int `json:"endColumn,omitempty"`
+}
+
+// drivePath matches the start of a Windows path with a drive letter.
+var drivePath = regexp.MustCompile(`^[A-Za-z]:[/\\]`)
+
+var untestedRule = Rule{
+	ID:               RuleID,
+	Name:             "UntestedFunction",
+	ShortDescription: Message{Text: "Function has no direct unit test"},
+	FullDescription: Message{
+		Text: "No test calls this function directly, so a change that breaks it is only caught by the tests of the code that calls it, if at all.",
+	},
+	HelpURI:              informationURI,
+	DefaultConfiguration: RuleConfiguration{Level: "warning"},
+	Properties:           map[string][]string{"tags": {"testing", "maintainability"}},
+}
+
+// New builds a log with a result for each of the provided functions without a direct unit test, keyed by the
+// import path of the package they're declared in. File locations are relative to a given root when they're in
+// it, which is usually the root of the repository. Results are sorted by location.
+func New(root string, untested map[string][]analysis.BlanketFunc) *Log {
+	run := Run{
+		Tool: Tool{
+			Driver: Driver{
+				Name:           "blanket",
+				Version:        analysis.Version,
+				InformationURI: informationURI,
+				Rules:          []Rule{untestedRule},
+			},
+		},
+		Results: []Result{},
+	}
+	if root != "" {
+		run.OriginalURIBaseIDs = map[string]ArtifactLocation{srcRoot: {URI: fileURI(root) + "/"}}
+	}
+
+	for importPath, funcs := range untested {
+		for _, f := range funcs {
+			run.Results = append(run.Results, newResult(root, importPath, f))
+		}
+	}
+	sort.Slice(run.Results, func(i, j int) bool {
+		a, b := run.Results[i].Locations[0].PhysicalLocation, run.Results[j].Locations[0].PhysicalLocation
+		if a.ArtifactLocation.URI != b.ArtifactLocation.URI {
+			return a.ArtifactLocation.URI < b.ArtifactLocation.URI
+		}
+		return a.Region.StartLine < b.Region.StartLine
+	})
+
+	return &Log{Schema: schemaURI, Version: version, Runs: []Run{run}}
+}
+
+// newResult describes a single function without a direct unit test. Its region spans from the func keyword
+// to the closing brace.
+func newResult(root, importPath string, f analysis.BlanketFunc) Result {
+	region := Region{StartLine: f.DeclPos.Line, StartColumn: f.DeclPos.Column}
+	if f.RBracePos.Line > 0 {
+		region.EndLine = f.RBracePos.Line
+		region.EndColumn = f.RBracePos.Column + 1
+	}
+
+	return Result{
+		RuleID:    RuleID,
+		RuleIndex: 0,
+		Level:     untestedRule.DefaultConfiguration.Level,
+		Message:   Message{Text: fmt.Sprintf("%s in package %s has no direct unit test", f.Name, importPath)},
+		Locations: []Location{{
+			PhysicalLocation: PhysicalLocation{
+				ArtifactLocation: artifactLocation(root, f.Filename),
+				Region:           region,
+			},
+		}},
+		PartialFingerprints: map[string]string{fingerprintKey: fingerprint(importPath, f.Name)},
+	}
+}
+
+// fingerprint identifies a function by the package it's in and its name, rather than its position, so that
+// dashboards keep tracking the same result when code around it moves.
+func fingerprint(importPath, name string) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{RuleID, importPath, name}, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+// artifactLocation returns the location of a file relative to a given root, or its absolute URI when it's
+// outside of the root (or there isn't one). Symlinks are resolved on both ends first.
+func artifactLocation(root, filename string) ArtifactLocation {
+	if resolved, err := filepath.EvalSymlinks(filename); err == nil {
+		filename = resolved
+	}
+	if root != "" {
+		if resolved, err := filepath.EvalSymlinks(root); err == nil {
+			root = resolved
+		}
+		if rel, err := filepath.Rel(root, filename); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return ArtifactLocation{URI: (&url.URL{Path: filepath.ToSlash(rel)}).String(), URIBaseID: srcRoot}
+		}
+	}
+	return ArtifactLocation{URI: fileURI(filename)}
+}
+
+// fileURI returns the file URI of a path, which is made absolute first if it's relative. Windows paths with a
+// drive letter, i.e. C:\src, are taken as absolute on every platform.
+func fileURI(path string) string {
+	if !drivePath.MatchString(path) {
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+	}
+	if drivePath.MatchString(path) {
+		// they need a leading slash, i.e. file:///C:/src
+		path = "/" + strings.ReplaceAll(path, `\`, "/")
+	}
+	path = filepath.ToSlash(path)
+	return (&url.URL{Scheme: "file", Path: strings.TrimSuffix(path, "/")}).String()
+}
+
+// Output writes a log with a result for each of the provided functions without a direct unit test (see New).
+func Output(w io.Writer, root string, untested map[string][]analysis.BlanketFunc) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(New(root, untested))
+}
@@ -0,0 +1,163 @@
+package sarif
+
+import (
+	"bytes"
+	"encoding/json"
+	"go/token"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gitlab.com/verygoodsoftwarenotvirus/blanket/analysis"
+
+	"github.com/stretchr/testify/assert"
+)
+
+////////////////////////////////////////////////////////
+//                                                    //
+//               Test Helper Functions                //
+//                                                    //
+////////////////////////////////////////////////////////
+
+var exampleRoot = filepath.FromSlash("/src/project")
+
+func buildExampleFunc(name, filename string, line int) analysis.BlanketFunc {
+	return analysis.BlanketFunc{
+		Name:      name,
+		Filename:  filepath.Join(exampleRoot, filepath.FromSlash(filename)),
+		DeclPos:   token.Position{Line: line, Column: 1},
+		RBracePos: token.Position{Line: line + 2, Column: 1},
+	}
+}
+
+////////////////////////////////////////////////////////
+//                                                    //
+//                   Actual Tests                     //
+//                                                    //
+////////////////////////////////////////////////////////
+
+func TestNew(t *testing.T) {
+	untested := map[string][]analysis.BlanketFunc{
+		"example.com/project/store": {buildExampleFunc("Store.Get", "store/store.go", 12)},
+		"example.com/project/api": {
+			buildExampleFunc("handle", "api/server.go", 20),
+			buildExampleFunc("Start", "api/server.go", 3),
+		},
+	}
+
+	actual := New(exampleRoot, untested)
+
+	assert.Equal(t, "2.1.0", actual.Version)
+	if !assert.Len(t, actual.Runs, 1) {
+		return
+	}
+	run := actual.Runs[0]
+	assert.Equal(t, "blanket", run.Tool.Driver.Name)
+	assert.Equal(t, RuleID, run.Tool.Driver.Rules[0].ID)
+	assert.Equal(t, "file:///src/project/", run.OriginalURIBaseIDs[srcRoot].URI)
+
+	if assert.Len(t, run.Results, 3) {
+		expected := Result{
+			RuleID:  RuleID,
+			Level:   "warning",
+			Message: Message{Text: "Start in package example.com/project/api has no direct unit test"},
+			Locations: []Location{{
+				PhysicalLocation: PhysicalLocation{
+					ArtifactLocation: ArtifactLocation{URI: "api/server.go", URIBaseID: srcRoot},
+					Region:           Region{StartLine: 3, StartColumn: 1, EndLine: 5, EndColumn: 2},
+				},
+			}},
+			PartialFingerprints: map[string]string{fingerprintKey: fingerprint("example.com/project/api", "Start")},
+		}
+		assert.Equal(t, expected, run.Results[0])
+		assert.Equal(t, 20, run.Results[1].Locations[0].PhysicalLocation.Region.StartLine)
+		assert.Equal(t, "store/store.go", run.Results[2].Locations[0].PhysicalLocation.ArtifactLocation.URI)
+	}
+
+	t.Run("without any untested functions", func(_t *testing.T) {
+		actual := New(exampleRoot, nil)
+		assert.NotNil(t, actual.Runs[0].Results, "a run without results should still list them, if empty")
+	})
+}
+
+func TestNewResult(t *testing.T) {
+	t.Run("fingerprints survive moving the function", func(_t *testing.T) {
+		before := newResult(exampleRoot, "example.com/project/api", buildExampleFunc("Start", "api/server.go", 3))
+		after := newResult(exampleRoot, "example.com/project/api", buildExampleFunc("Start", "api/server.go", 30))
+
+		assert.Equal(t, before.PartialFingerprints, after.PartialFingerprints)
+	})
+
+	t.Run("fingerprints tell functions apart", func(_t *testing.T) {
+		a := newResult(exampleRoot, "example.com/project/api", buildExampleFunc("Start", "api/server.go", 3))
+		b := newResult(exampleRoot, "example.com/project/store", buildExampleFunc("Start", "store/store.go", 3))
+
+		assert.NotEqual(t, a.PartialFingerprints, b.PartialFingerprints)
+	})
+
+	t.Run("without a body", func(_t *testing.T) {
+		f := analysis.BlanketFunc{Name: "add", Filename: filepath.Join(exampleRoot, "add.go"), DeclPos: token.Position{Line: 3, Column: 1}}
+
+		actual := newResult(exampleRoot, "example.com/project", f)
+
+		assert.Equal(t, Region{StartLine: 3, StartColumn: 1}, actual.Locations[0].PhysicalLocation.Region)
+	})
+}
+
+func TestArtifactLocation(t *testing.T) {
+	t.Run("inside of the root", func(_t *testing.T) {
+		actual := artifactLocation(exampleRoot, filepath.Join(exampleRoot, "api", "my server.go"))
+		assert.Equal(t, ArtifactLocation{URI: "api/my%20server.go", URIBaseID: srcRoot}, actual)
+	})
+
+	t.Run("outside of the root", func(_t *testing.T) {
+		actual := artifactLocation(exampleRoot, filepath.FromSlash("/src/other/thing.go"))
+		assert.Equal(t, ArtifactLocation{URI: "file:///src/other/thing.go"}, actual)
+	})
+
+	t.Run("without a root", func(_t *testing.T) {
+		actual := artifactLocation("", filepath.Join(exampleRoot, "thing.go"))
+		assert.Equal(t, ArtifactLocation{URI: "file:///src/project/thing.go"}, actual)
+	})
+}
+
+func TestFileURI(t *testing.T) {
+	t.Run("absolute path", func(_t *testing.T) {
+		assert.Equal(t, "file:///src/my%20project", fileURI(filepath.FromSlash("/src/my project/")))
+	})
+
+	t.Run("Windows path", func(_t *testing.T) {
+		assert.Equal(t, "file:///C:/src/project", fileURI(`C:\src\project`))
+		assert.Equal(t, "file:///C:/src/project", fileURI("C:/src/project"))
+	})
+
+	t.Run("relative path", func(_t *testing.T) {
+		wd, err := os.Getwd()
+		if err != nil {
+			t.Logf("failing because Getwd returned error: %v", err)
+			t.FailNow()
+		}
+
+		expected := (&url.URL{Scheme: "file", Path: filepath.ToSlash(filepath.Join(wd, "project"))}).String()
+		if filepath.VolumeName(wd) != "" {
+			expected = (&url.URL{Scheme: "file", Path: "/" + filepath.ToSlash(filepath.Join(wd, "project"))}).String()
+		}
+		assert.Equal(t, expected, fileURI("project"))
+	})
+}
+
+func TestOutput(t *testing.T) {
+	var buf bytes.Buffer
+	untested := map[string][]analysis.BlanketFunc{
+		"example.com/project/api": {buildExampleFunc("Start", "api/server.go", 3)},
+	}
+
+	err := Output(&buf, exampleRoot, untested)
+	assert.NoError(t, err)
+
+	var actual map[string]interface{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &actual))
+	assert.Equal(t, schemaURI, actual["$schema"])
+	assert.Equal(t, "2.1.0", actual["version"])
+}